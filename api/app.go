@@ -2,9 +2,39 @@ package api
 
 import (
 	"database/sql"
+	"net/http"
+	"time"
 )
 
 type App struct {
 	DB     *sql.DB
 	JWTKey []byte
+
+	EnforceBlockers bool
+	TrashRetention  time.Duration
+
+	GitWebhookSecret string
+}
+
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func respondError(w http.ResponseWriter, err error, message string) {
+	if apiErr, ok := err.(*apiError); ok {
+		http.Error(w, apiErr.Message, apiErr.Status)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
 }
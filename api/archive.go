@@ -0,0 +1,219 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (app *App) ArchiveTaskHandler(w http.ResponseWriter, r *http.Request) {
+	app.setTaskArchived(w, r, true)
+}
+
+func (app *App) UnarchiveTaskHandler(w http.ResponseWriter, r *http.Request) {
+	app.setTaskArchived(w, r, false)
+}
+
+func (app *App) setTaskArchived(w http.ResponseWriter, r *http.Request, archive bool) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var task types.Task
+	err = scanTask(app.DB.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND deleted_at IS NULL", taskID), &task)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching task", http.StatusInternalServerError)
+		return
+	}
+
+	if archive == (task.ArchivedAt != nil) {
+		if archive {
+			http.Error(w, "Task is already archived", http.StatusConflict)
+		} else {
+			http.Error(w, "Task is not archived", http.StatusConflict)
+		}
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	actionType, logMessage := "unarchive", "Task unarchived"
+	previousArchivedAt := task.ArchivedAt
+	task.ArchivedAt = nil
+	if archive {
+		archivedAt := time.Now().UTC()
+		task.ArchivedAt = &archivedAt
+		actionType, logMessage = "archive", "Task archived"
+	}
+
+	// An unarchived task counts toward its column's load again.
+	var warning string
+	if !archive {
+		warning, err = admitToColumn(tx, task.ColumnID)
+		if err != nil {
+			respondError(w, err, "Database error while checking WIP limit")
+			return
+		}
+	}
+
+	_, err = tx.Exec("UPDATE tasks SET archived_at = $1 WHERE id = $2", task.ArchivedAt, taskID)
+	if err != nil {
+		http.Error(w, "Error updating task", http.StatusInternalServerError)
+		return
+	}
+	changes := recordChange(nil, "archived_at", previousArchivedAt, task.ArchivedAt)
+	if err := logTaskAction(tx, taskID, app.optionalUserID(r), actionType, logMessage, changes...); err != nil {
+		http.Error(w, "Error logging task update", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating task", http.StatusInternalServerError)
+		return
+	}
+
+	tasks := []types.Task{task}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+	if warning != "" {
+		tasks[0].Warnings = []string{warning}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks[0])
+}
+
+func (app *App) ArchiveColumnTasksHandler(w http.ResponseWriter, r *http.Request) {
+	columnID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid column ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := app.columnProjectID(columnID); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Column not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while checking column", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	archivedAt := time.Now().UTC()
+	rows, err := tx.Query(`UPDATE tasks SET archived_at = $2
+		WHERE column_id = $1 AND archived_at IS NULL AND deleted_at IS NULL
+		RETURNING id`, columnID, archivedAt)
+	if err != nil {
+		http.Error(w, "Error archiving tasks", http.StatusInternalServerError)
+		return
+	}
+
+	var taskIDs []int
+	for rows.Next() {
+		var taskID int
+		if err := rows.Scan(&taskID); err != nil {
+			rows.Close()
+			http.Error(w, "Error archiving tasks", http.StatusInternalServerError)
+			return
+		}
+		taskIDs = append(taskIDs, taskID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error archiving tasks", http.StatusInternalServerError)
+		return
+	}
+
+	actorID := app.optionalUserID(r)
+	changes := recordChange(nil, "archived_at", nil, archivedAt)
+	for _, taskID := range taskIDs {
+		if err := logTaskAction(tx, taskID, actorID, "archive", "Task archived with its column", changes...); err != nil {
+			http.Error(w, "Error logging task update", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error archiving tasks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"archived": len(taskIDs)})
+}
+
+func (app *App) ArchiveBoardHandler(w http.ResponseWriter, r *http.Request) {
+	app.setBoardArchived(w, r, true)
+}
+
+func (app *App) UnarchiveBoardHandler(w http.ResponseWriter, r *http.Request) {
+	app.setBoardArchived(w, r, false)
+}
+
+func (app *App) setBoardArchived(w http.ResponseWriter, r *http.Request, archive bool) {
+	boardID := chi.URLParam(r, "id")
+	if boardID == "" {
+		http.Error(w, "Board ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var board types.Board
+	err := app.DB.QueryRow("SELECT id, project_id, name, archived_at FROM boards WHERE id = $1 AND deleted_at IS NULL", boardID).
+		Scan(&board.ID, &board.ProjectID, &board.Name, &board.ArchivedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Board not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching board", http.StatusInternalServerError)
+		return
+	}
+
+	if archive == (board.ArchivedAt != nil) {
+		if archive {
+			http.Error(w, "Board is already archived", http.StatusConflict)
+		} else {
+			http.Error(w, "Board is not archived", http.StatusConflict)
+		}
+		return
+	}
+
+	board.ArchivedAt = nil
+	if archive {
+		archivedAt := time.Now().UTC()
+		board.ArchivedAt = &archivedAt
+	}
+
+	_, err = app.DB.Exec("UPDATE boards SET archived_at = $1 WHERE id = $2", board.ArchivedAt, board.ID)
+	if err != nil {
+		http.Error(w, "Error updating board", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
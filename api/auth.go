@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"kanban-board/types"
@@ -132,3 +133,33 @@ func (app *App) GenerateToken(email string) (string, error) {
 	}
 	return tokenString, nil
 }
+
+func (app *App) optionalUserID(r *http.Request) int {
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		return 0
+	}
+	return userID
+}
+
+func (app *App) userIDFromRequest(r *http.Request) (int, error) {
+	tokenString, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || tokenString == "" {
+		return 0, &apiError{http.StatusUnauthorized, "Authorization token is required"}
+	}
+
+	claims := &types.Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return app.JWTKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return 0, &apiError{http.StatusUnauthorized, "Invalid or expired token"}
+	}
+
+	var userID int
+	err = app.DB.QueryRow("SELECT id FROM users WHERE email = $1", claims.Email).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, &apiError{http.StatusUnauthorized, "User not found"}
+	}
+	return userID, err
+}
@@ -6,8 +6,10 @@ import (
 	"kanban-board/types"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
 )
 
 func (app *App) CreateBoardHandler(w http.ResponseWriter, r *http.Request) {
@@ -25,7 +27,7 @@ func (app *App) CreateBoardHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var projectExists bool
-	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", board.ProjectID).Scan(&projectExists)
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1 AND deleted_at IS NULL)", board.ProjectID).Scan(&projectExists)
 	if err != nil {
 		http.Error(w, "Database error while checking project", http.StatusInternalServerError)
 		return
@@ -36,7 +38,7 @@ func (app *App) CreateBoardHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var existingBoardName string
-	err = app.DB.QueryRow("SELECT name FROM boards WHERE name = $1", board.Name).Scan(&existingBoardName)
+	err = app.DB.QueryRow("SELECT name FROM boards WHERE name = $1 AND deleted_at IS NULL", board.Name).Scan(&existingBoardName)
 	if err != nil && err != sql.ErrNoRows {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -46,7 +48,20 @@ func (app *App) CreateBoardHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.DB.QueryRow("INSERT INTO boards (project_id, name) VALUES ($1, $2) RETURNING id",
+	template, err := boardTemplateByRef(app.DB, board.ProjectID, board.Template)
+	if err != nil {
+		respondError(w, err, "Database error while fetching board template")
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow("INSERT INTO boards (project_id, name) VALUES ($1, $2) RETURNING id",
 		board.ProjectID, board.Name).Scan(&board.ID)
 	if err != nil {
 		http.Error(w, "Error creating board", http.StatusInternalServerError)
@@ -55,35 +70,28 @@ func (app *App) CreateBoardHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Board created with ID: %d", board.ID)
 
-	_, err = app.DB.Exec(`INSERT INTO columns (board_id, status) VALUES
-	($1, 'todo'),
-	($1, 'doing'),
-	($1, 'done')`,
-		board.ID)
-	if err != nil {
+	if err := applyBoardTemplate(tx, board.ID, template); err != nil {
 		log.Printf("Error creating columns: %v", err)
 		http.Error(w, "Error creating columns", http.StatusInternalServerError)
 		return
 	}
 
+	columns, err := boardColumns(tx, board.ID)
+	if err != nil {
+		http.Error(w, "Database error while fetching columns", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating board", http.StatusInternalServerError)
+		return
+	}
+
 	response := types.Board{
 		ID:        board.ID,
 		ProjectID: board.ProjectID,
 		Name:      board.Name,
-		Columns: []types.Column{
-			{
-				BoardID: board.ID,
-				Status:  "todo",
-			},
-			{
-				BoardID: board.ID,
-				Status:  "doing",
-			},
-			{
-				BoardID: board.ID,
-				Status:  "done",
-			},
-		},
+		Labels:    template.Labels,
+		Columns:   columns,
 	}
 
 	w.Header().Set("Content-type", "application/json")
@@ -94,8 +102,8 @@ func (app *App) GetBoardByID(w http.ResponseWriter, r *http.Request) {
 	boardID := chi.URLParam(r, "id")
 	var board types.Board
 
-	err := app.DB.QueryRow("SELECT id, project_id, name FROM boards WHERE id = $1", boardID).
-		Scan(&board.ID, &board.ProjectID, &board.Name)
+	err := app.DB.QueryRow("SELECT id, project_id, name, swimlane_field, labels, archived_at FROM boards WHERE id = $1 AND deleted_at IS NULL", boardID).
+		Scan(&board.ID, &board.ProjectID, &board.Name, &board.SwimlaneField, pq.Array(&board.Labels), &board.ArchivedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Board not found", http.StatusNotFound)
@@ -105,12 +113,23 @@ func (app *App) GetBoardByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	board.Columns, err = boardColumns(app.DB, board.ID)
+	if err != nil {
+		http.Error(w, "Database error while fetching columns", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(board)
 }
 
 func (app *App) GetBoards(w http.ResponseWriter, r *http.Request) {
-	rows, err := app.DB.Query("SELECT id, project_id, name FROM boards")
+	query := "SELECT id, project_id, name, archived_at FROM boards WHERE deleted_at IS NULL"
+	if r.URL.Query().Get("include_archived") != "true" {
+		query += " AND archived_at IS NULL"
+	}
+
+	rows, err := app.DB.Query(query)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -120,7 +139,7 @@ func (app *App) GetBoards(w http.ResponseWriter, r *http.Request) {
 	var boards []types.Board
 	for rows.Next() {
 		var board types.Board
-		if err := rows.Scan(&board.ID, &board.ProjectID, &board.Name); err != nil {
+		if err := rows.Scan(&board.ID, &board.ProjectID, &board.Name, &board.ArchivedAt); err != nil {
 			http.Error(w, "Error scanning boards", http.StatusInternalServerError)
 			return
 		}
@@ -138,20 +157,19 @@ func (app *App) DeleteBoardHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := app.DB.Exec("DELETE FROM boards WHERE id = $1", boardID)
+	id, err := strconv.Atoi(boardID)
 	if err != nil {
-		http.Error(w, "Database error while deleting board", http.StatusInternalServerError)
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	err = app.moveToTrash(r, "board", id)
 	if err != nil {
-		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
-		return
-	}
-
-	if rowsAffected == 0 {
-		http.Error(w, "Board not found", http.StatusNotFound)
+		if apiErr, ok := err.(*apiError); ok && apiErr.Status == http.StatusNotFound {
+			http.Error(w, "Board not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while deleting board", http.StatusInternalServerError)
 		return
 	}
 
@@ -174,7 +192,7 @@ func (app *App) UpdateBoardNameHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var existingBoard types.Board
-	err = app.DB.QueryRow("SELECT id, project_id, name FROM boards WHERE id = $1",
+	err = app.DB.QueryRow("SELECT id, project_id, name FROM boards WHERE id = $1 AND deleted_at IS NULL",
 		boardID).Scan(&existingBoard.ID, &existingBoard.ProjectID, &existingBoard.Name)
 	if err != nil {
 		if err == sql.ErrNoRows {
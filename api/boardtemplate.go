@@ -0,0 +1,424 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const boardTemplateColumns = "id, project_id, name, description, columns, labels, created_at"
+
+// defaultBoardTemplate is used when a board is created without a template and
+// keeps the todo/doing/done columns boards have always started with.
+const defaultBoardTemplate = "basic"
+
+func wipLimit(n int) *int {
+	return &n
+}
+
+var builtinBoardTemplates = []types.BoardTemplate{
+	{
+		Key:         defaultBoardTemplate,
+		BuiltIn:     true,
+		Name:        "Basic",
+		Description: "A simple todo, doing, done board.",
+		Columns: []types.BoardTemplateColumn{
+			{Status: "todo", Category: CategoryTodo},
+			{Status: "doing", Category: CategoryInProgress},
+			{Status: "done", Category: CategoryDone},
+		},
+		Labels: []string{},
+	},
+	{
+		Key:         "kanban",
+		BuiltIn:     true,
+		Name:        "Kanban",
+		Description: "Continuous flow with WIP limits on the working columns.",
+		Columns: []types.BoardTemplateColumn{
+			{Status: "backlog", Category: CategoryBacklog},
+			{Status: "ready", Category: CategoryTodo, WIPMax: wipLimit(10)},
+			{Status: "in progress", Category: CategoryInProgress, WIPMax: wipLimit(5)},
+			{Status: "review", Category: CategoryInProgress, WIPMax: wipLimit(3)},
+			{Status: "done", Category: CategoryDone},
+		},
+		Labels: []string{"expedite", "blocked"},
+	},
+	{
+		Key:         "scrum",
+		BuiltIn:     true,
+		Name:        "Scrum",
+		Description: "Sprint board from sprint backlog to done.",
+		Columns: []types.BoardTemplateColumn{
+			{Status: "backlog", Category: CategoryBacklog},
+			{Status: "sprint backlog", Category: CategoryTodo},
+			{Status: "in progress", Category: CategoryInProgress},
+			{Status: "review", Category: CategoryInProgress},
+			{Status: "done", Category: CategoryDone},
+		},
+		Labels: []string{"story", "bug", "task", "spike"},
+	},
+	{
+		Key:         "bug-triage",
+		BuiltIn:     true,
+		Name:        "Bug triage",
+		Description: "Incoming bugs from report to verified fix.",
+		Columns: []types.BoardTemplateColumn{
+			{Status: "new", Category: CategoryBacklog},
+			{Status: "triaged", Category: CategoryTodo},
+			{Status: "in progress", Category: CategoryInProgress, WIPMax: wipLimit(5)},
+			{Status: "verifying", Category: CategoryInProgress},
+			{Status: "done", Category: CategoryDone},
+		},
+		Labels: []string{"critical", "major", "minor", "needs repro"},
+	},
+}
+
+func scanBoardTemplate(row scanner, template *types.BoardTemplate) error {
+	var projectID int
+	var columns []byte
+	err := row.Scan(&template.ID, &projectID, &template.Name, &template.Description, &columns,
+		pq.Array(&template.Labels), &template.CreatedAt)
+	if err != nil {
+		return err
+	}
+	template.ProjectID = &projectID
+	if template.Labels == nil {
+		template.Labels = []string{}
+	}
+	return json.Unmarshal(columns, &template.Columns)
+}
+
+func normalizeBoardTemplate(template *types.BoardTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" || len([]rune(template.Name)) > maxNameLength {
+		return &apiError{http.StatusBadRequest, "Template name must be between 1 and 100 characters"}
+	}
+	if len(template.Columns) == 0 {
+		return &apiError{http.StatusBadRequest, "A board template needs at least one column"}
+	}
+
+	seen := make(map[string]bool, len(template.Columns))
+	for i := range template.Columns {
+		column := &template.Columns[i]
+		column.Status = strings.TrimSpace(column.Status)
+		if column.Status == "" || len([]rune(column.Status)) > maxNameLength {
+			return &apiError{http.StatusBadRequest, "Column status must be between 1 and 100 characters"}
+		}
+		if seen[column.Status] {
+			return &apiError{http.StatusBadRequest, fmt.Sprintf("Column %q appears more than once", column.Status)}
+		}
+		seen[column.Status] = true
+
+		limits := types.WIPLimits{WIPMin: column.WIPMin, WIPMax: column.WIPMax, WIPMode: column.WIPMode}
+		if err := validateWIPLimits(&limits); err != nil {
+			return err
+		}
+		column.WIPMode = limits.WIPMode
+		if err := validateCategory(&column.Category, column.Status); err != nil {
+			return err
+		}
+	}
+
+	labels, err := normalizeLabels(template.Labels)
+	if err != nil {
+		return err
+	}
+	template.Labels = labels
+	return nil
+}
+
+// boardTemplateByRef finds the template a board is created from: a built-in
+// template by key, or one of the project's templates by ID.
+func boardTemplateByRef(db querier, projectID int, ref string) (types.BoardTemplate, error) {
+	if ref == "" {
+		ref = defaultBoardTemplate
+	}
+	for _, template := range builtinBoardTemplates {
+		if template.Key == ref {
+			return template, nil
+		}
+	}
+
+	var template types.BoardTemplate
+	templateID, err := strconv.Atoi(ref)
+	if err != nil {
+		return template, &apiError{http.StatusBadRequest, fmt.Sprintf("Unknown board template %q", ref)}
+	}
+	err = scanBoardTemplate(db.QueryRow("SELECT "+boardTemplateColumns+" FROM board_templates WHERE id = $1 AND project_id = $2",
+		templateID, projectID), &template)
+	if err == sql.ErrNoRows {
+		return template, &apiError{http.StatusNotFound, "Board template not found"}
+	}
+	return template, err
+}
+
+// applyBoardTemplate creates the template's columns on a new board and gives
+// the board the template's labels.
+func applyBoardTemplate(db querier, boardID int, template types.BoardTemplate) error {
+	for position, column := range template.Columns {
+		mode := column.WIPMode
+		if mode == "" {
+			mode = WIPSoft
+		}
+		category := column.Category
+		if category == "" {
+			category = CategoryTodo
+		}
+		_, err := db.Exec(`INSERT INTO columns (board_id, status, category, position, wip_min, wip_max, wip_mode)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`, boardID, column.Status, category, position, column.WIPMin, column.WIPMax, mode)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec("UPDATE boards SET labels = $1 WHERE id = $2", pq.Array(template.Labels), boardID)
+	return err
+}
+
+func insertBoardTemplate(db querier, projectID int, template *types.BoardTemplate) error {
+	columns, err := json.Marshal(template.Columns)
+	if err != nil {
+		return err
+	}
+	err = scanBoardTemplate(db.QueryRow(`INSERT INTO board_templates (project_id, name, description, columns, labels)
+		VALUES ($1, $2, $3, $4, $5) RETURNING `+boardTemplateColumns,
+		projectID, template.Name, template.Description, columns, pq.Array(template.Labels)), template)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return &apiError{http.StatusConflict, "This board template already exists"}
+	}
+	return err
+}
+
+// GetBoardTemplates lists the built-in templates followed by the project's own.
+func (app *App) GetBoardTemplates(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := app.DB.Query("SELECT "+boardTemplateColumns+" FROM board_templates WHERE project_id = $1 ORDER BY id", projectID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	templates := append([]types.BoardTemplate{}, builtinBoardTemplates...)
+	for rows.Next() {
+		var template types.BoardTemplate
+		if err := scanBoardTemplate(rows, &template); err != nil {
+			http.Error(w, "Error scanning board templates", http.StatusInternalServerError)
+			return
+		}
+		templates = append(templates, template)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+func (app *App) CreateBoardTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	var template types.BoardTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := normalizeBoardTemplate(&template); err != nil {
+		respondError(w, err, "Invalid board template")
+		return
+	}
+
+	var projectExists bool
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1 AND deleted_at IS NULL)", projectID).Scan(&projectExists)
+	if err != nil {
+		http.Error(w, "Database error while checking project", http.StatusInternalServerError)
+		return
+	}
+	if !projectExists {
+		http.Error(w, "Project does not exist", http.StatusNotFound)
+		return
+	}
+
+	if err := insertBoardTemplate(app.DB, projectID, &template); err != nil {
+		respondError(w, err, "Error creating board template")
+		return
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+func (app *App) GetBoardTemplateByID(w http.ResponseWriter, r *http.Request) {
+	templateID := chi.URLParam(r, "id")
+	var template types.BoardTemplate
+
+	err := scanBoardTemplate(app.DB.QueryRow("SELECT "+boardTemplateColumns+" FROM board_templates WHERE id = $1", templateID), &template)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Board template not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+func (app *App) UpdateBoardTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	templateID := chi.URLParam(r, "id")
+	if templateID == "" {
+		http.Error(w, "Template ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var updateData types.BoardTemplate
+	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var template types.BoardTemplate
+	err := scanBoardTemplate(app.DB.QueryRow("SELECT "+boardTemplateColumns+" FROM board_templates WHERE id = $1", templateID), &template)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Board template not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching board template", http.StatusInternalServerError)
+		return
+	}
+
+	if updateData.Name != "" {
+		template.Name = updateData.Name
+	}
+	if updateData.Description != "" {
+		template.Description = updateData.Description
+	}
+	if updateData.Columns != nil {
+		template.Columns = updateData.Columns
+	}
+	if updateData.Labels != nil {
+		template.Labels = updateData.Labels
+	}
+	if err := normalizeBoardTemplate(&template); err != nil {
+		respondError(w, err, "Invalid board template")
+		return
+	}
+
+	columns, err := json.Marshal(template.Columns)
+	if err != nil {
+		http.Error(w, "Error encoding board template columns", http.StatusInternalServerError)
+		return
+	}
+	_, err = app.DB.Exec("UPDATE board_templates SET name = $1, description = $2, columns = $3, labels = $4 WHERE id = $5",
+		template.Name, template.Description, columns, pq.Array(template.Labels), template.ID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "This board template already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Error updating board template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+func (app *App) DeleteBoardTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	templateID := chi.URLParam(r, "id")
+	if templateID == "" {
+		http.Error(w, "Template ID is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.DB.Exec("DELETE FROM board_templates WHERE id = $1", templateID)
+	if err != nil {
+		http.Error(w, "Database error while deleting board template", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Board template not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Board template deleted successfully"))
+}
+
+// SaveBoardAsTemplateHandler stores a board's columns, WIP limits and labels
+// as a template of its project.
+func (app *App) SaveBoardAsTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+
+	var template types.BoardTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var projectID int
+	var boardName string
+	err = app.DB.QueryRow("SELECT project_id, name, labels FROM boards WHERE id = $1 AND deleted_at IS NULL", boardID).
+		Scan(&projectID, &boardName, pq.Array(&template.Labels))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Board not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching board", http.StatusInternalServerError)
+		return
+	}
+
+	columns, err := boardColumns(app.DB, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching columns", http.StatusInternalServerError)
+		return
+	}
+	template.Columns = make([]types.BoardTemplateColumn, len(columns))
+	for i, column := range columns {
+		template.Columns[i] = types.BoardTemplateColumn{Status: column.Status, Category: column.Category,
+			WIPMin: column.WIPMin, WIPMax: column.WIPMax, WIPMode: column.WIPMode}
+	}
+	if template.Name == "" {
+		template.Name = boardName
+	}
+	if err := normalizeBoardTemplate(&template); err != nil {
+		respondError(w, err, "Invalid board template")
+		return
+	}
+
+	if err := insertBoardTemplate(app.DB, projectID, &template); err != nil {
+		respondError(w, err, "Error saving board template")
+		return
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
@@ -0,0 +1,360 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	BulkMove         = "move"
+	BulkAssign       = "assign"
+	BulkAddLabels    = "add_labels"
+	BulkRemoveLabels = "remove_labels"
+	BulkArchive      = "archive"
+	BulkDelete       = "delete"
+)
+
+const maxBulkTasks = 500
+
+// bulkTaskConditions turns the explicit task IDs or the filter of a bulk
+// request into task conditions.
+func bulkTaskConditions(request types.BulkTaskRequest) ([]string, []any, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+
+	if len(request.TaskIDs) > 0 {
+		ids := make([]int64, len(request.TaskIDs))
+		for i, id := range request.TaskIDs {
+			ids[i] = int64(id)
+		}
+		args = append(args, pq.Array(ids))
+		conditions = append(conditions, "id = ANY($1)")
+		return conditions, args, nil
+	}
+
+	filter := request.Filter
+	if filter == nil || (filter.ColumnID == 0 && filter.ProjectID == 0 && filter.AssigneeID == 0 && filter.Label == "") {
+		return nil, nil, &apiError{http.StatusBadRequest, "Either task_ids or a filter is required"}
+	}
+	if filter.ColumnID != 0 {
+		args = append(args, filter.ColumnID)
+		conditions = append(conditions, "column_id = $"+strconv.Itoa(len(args)))
+	}
+	if filter.ProjectID != 0 {
+		args = append(args, filter.ProjectID)
+		conditions = append(conditions, `column_id IN (SELECT columns.id FROM columns
+			JOIN boards ON boards.id = columns.board_id WHERE boards.project_id = $`+strconv.Itoa(len(args))+")")
+	}
+	if filter.AssigneeID != 0 {
+		args = append(args, filter.AssigneeID)
+		conditions = append(conditions, "assignee_id = $"+strconv.Itoa(len(args)))
+	}
+	if filter.Label != "" {
+		args = append(args, filter.Label)
+		conditions = append(conditions, "id IN (SELECT task_id FROM task_labels WHERE label = $"+strconv.Itoa(len(args))+")")
+	}
+	if !filter.IncludeArchived {
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+	return conditions, args, nil
+}
+
+func validateBulkOperation(request *types.BulkTaskRequest) error {
+	switch request.Operation {
+	case BulkMove:
+		if request.ColumnID == 0 {
+			return &apiError{http.StatusBadRequest, "column_id is required to move tasks"}
+		}
+	case BulkAssign:
+		if request.AssigneeID == nil {
+			return &apiError{http.StatusBadRequest, "assignee_id is required to assign tasks"}
+		}
+	case BulkAddLabels, BulkRemoveLabels:
+		labels, err := normalizeLabels(request.Labels)
+		if err != nil {
+			return err
+		}
+		if len(labels) == 0 {
+			return &apiError{http.StatusBadRequest, "labels are required for label operations"}
+		}
+		request.Labels = labels
+	case BulkArchive, BulkDelete:
+	default:
+		return &apiError{http.StatusBadRequest, "Operation must be one of: move, assign, add_labels, remove_labels, archive, delete"}
+	}
+	return nil
+}
+
+func (app *App) BulkTaskHandler(w http.ResponseWriter, r *http.Request) {
+	actorID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	var request types.BulkTaskRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := validateBulkOperation(&request); err != nil {
+		respondError(w, err, "Invalid bulk operation")
+		return
+	}
+
+	conditions, args, err := bulkTaskConditions(request)
+	if err != nil {
+		respondError(w, err, "Invalid bulk operation")
+		return
+	}
+
+	bulk := bulkContext{actorID: actorID, now: time.Now().UTC().Truncate(time.Microsecond)}
+	if request.Operation == BulkMove {
+		err = app.DB.QueryRow(`SELECT columns.category, boards.project_id FROM columns
+			JOIN boards ON boards.id = columns.board_id
+			WHERE columns.id = $1 AND columns.deleted_at IS NULL`, request.ColumnID).Scan(&bulk.targetCategory, &bulk.targetProjectID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Column does not exist", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Database error while checking column", http.StatusInternalServerError)
+			return
+		}
+		if err := app.checkProjectMember(bulk.targetProjectID, actorID); err != nil {
+			respondError(w, err, "Database error while checking project membership")
+			return
+		}
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT "+taskColumns+" FROM tasks WHERE "+strings.Join(conditions, " AND ")+
+		" ORDER BY id LIMIT "+strconv.Itoa(maxBulkTasks+1)+" FOR UPDATE", args...)
+	if err != nil {
+		http.Error(w, "Database error while fetching tasks", http.StatusInternalServerError)
+		return
+	}
+	var tasks []types.Task
+	for rows.Next() {
+		var task types.Task
+		if err := scanTask(rows, &task); err != nil {
+			rows.Close()
+			http.Error(w, "Error scanning tasks", http.StatusInternalServerError)
+			return
+		}
+		tasks = append(tasks, task)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error scanning tasks", http.StatusInternalServerError)
+		return
+	}
+
+	if len(tasks) > maxBulkTasks {
+		http.Error(w, fmt.Sprintf("Bulk operations are limited to %d tasks", maxBulkTasks), http.StatusBadRequest)
+		return
+	}
+	if len(request.TaskIDs) > 0 {
+		if missing := missingTaskIDs(request.TaskIDs, tasks); len(missing) > 0 {
+			http.Error(w, fmt.Sprintf("Tasks not found: %v", missing), http.StatusNotFound)
+			return
+		}
+	}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+
+	projects := make(map[int]int)
+	members := make(map[int]bool)
+	result := types.BulkTaskResult{Operation: request.Operation, TaskIDs: []int{}}
+
+	for _, task := range tasks {
+		projectID, ok := projects[task.ColumnID]
+		if !ok {
+			projectID, err = app.columnProjectID(task.ColumnID)
+			if err != nil {
+				http.Error(w, "Database error while fetching project", http.StatusInternalServerError)
+				return
+			}
+			projects[task.ColumnID] = projectID
+		}
+		if !members[projectID] {
+			if err := app.checkProjectMember(projectID, actorID); err != nil {
+				respondError(w, taskError(task, err), "Database error while checking project membership")
+				return
+			}
+			members[projectID] = true
+		}
+
+		if request.Operation == BulkMove && task.ColumnID != request.ColumnID {
+			if err := checkTransition(tx, task, request.ColumnID, actorID); err != nil {
+				respondError(w, taskError(task, err), "Database error while checking workflow")
+				return
+			}
+			warning, err := admitToColumn(tx, request.ColumnID)
+			if err != nil {
+				respondError(w, taskError(task, err), "Database error while checking WIP limit")
+				return
+			}
+			if warning != "" {
+				// Every move past the limit warns; only the final load is reported.
+				result.Warnings = []string{warning}
+			}
+		}
+
+		changes, err := app.applyBulkOperation(tx, request, task, projectID, bulk)
+		if err != nil {
+			respondError(w, taskError(task, err), "Error applying bulk operation")
+			return
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		err = logTaskAction(tx, task.ID, actorID, request.Operation, "Task updated by bulk "+request.Operation, changes...)
+		if err != nil {
+			http.Error(w, "Error logging bulk operation", http.StatusInternalServerError)
+			return
+		}
+		result.TaskIDs = append(result.TaskIDs, task.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error applying bulk operation", http.StatusInternalServerError)
+		return
+	}
+	result.Updated = len(result.TaskIDs)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+type bulkContext struct {
+	actorID         int
+	targetCategory  string
+	targetProjectID int
+	now             time.Time
+}
+
+func (app *App) applyBulkOperation(tx querier, request types.BulkTaskRequest, task types.Task, projectID int,
+	bulk bulkContext) ([]types.FieldChange, error) {
+	switch request.Operation {
+	case BulkMove:
+		if task.ColumnID == request.ColumnID {
+			return nil, nil
+		}
+		if app.EnforceBlockers && bulk.targetCategory == CategoryDone && hasUnresolvedBlockers(task) {
+			return nil, &apiError{http.StatusConflict, "Task has unresolved blockers"}
+		}
+		changes := recordChange(nil, "column_id", task.ColumnID, request.ColumnID)
+		if projectID != bulk.targetProjectID {
+			moved, err := moveToProject(tx, &task, bulk.targetProjectID, bulk.actorID)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, moved...)
+		}
+		_, err := tx.Exec("UPDATE tasks SET column_id = $1, "+movedPlacement+" WHERE id = $2", request.ColumnID, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		return changes, trackCategory(tx, &task, bulk.now)
+
+	case BulkAssign:
+		assigneeID := request.AssigneeID
+		if *assigneeID == 0 {
+			assigneeID = nil
+		} else if err := app.checkProjectMember(projectID, *assigneeID); err != nil {
+			return nil, err
+		}
+		changes := recordChange(nil, "assignee_id", task.AssigneeID, assigneeID)
+		if len(changes) == 0 {
+			return nil, nil
+		}
+		if _, err := tx.Exec("UPDATE tasks SET assignee_id = $1 WHERE id = $2", assigneeID, task.ID); err != nil {
+			return nil, err
+		}
+		if assigneeID != nil {
+			if err := addWatcher(tx, task.ID, *assigneeID); err != nil {
+				return nil, err
+			}
+		}
+		return changes, nil
+
+	case BulkAddLabels, BulkRemoveLabels:
+		remove := make(map[string]bool)
+		labels := append([]string{}, task.Labels...)
+		if request.Operation == BulkAddLabels {
+			labels = append(labels, request.Labels...)
+		} else {
+			for _, label := range request.Labels {
+				remove[label] = true
+			}
+		}
+		updated := []string{}
+		seen := make(map[string]bool)
+		for _, label := range labels {
+			if !remove[label] && !seen[label] {
+				seen[label] = true
+				updated = append(updated, label)
+			}
+		}
+		changes := recordChange(nil, "labels", append([]string{}, task.Labels...), updated)
+		if len(changes) == 0 {
+			return nil, nil
+		}
+		return changes, setTaskLabels(tx, task.ID, updated)
+
+	case BulkArchive:
+		if task.ArchivedAt != nil {
+			return nil, nil
+		}
+		_, err := tx.Exec("UPDATE tasks SET archived_at = $1 WHERE id = $2", bulk.now, task.ID)
+		return recordChange(nil, "archived_at", nil, bulk.now), err
+
+	case BulkDelete:
+		if err := softDelete(tx, "task", task.ID, bulk.actorID, bulk.now); err != nil {
+			return nil, err
+		}
+		return recordChange(nil, "deleted_at", nil, bulk.now), nil
+	}
+	return nil, nil
+}
+
+func missingTaskIDs(requested []int, tasks []types.Task) []int {
+	found := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		found[task.ID] = true
+	}
+	var missing []int
+	for _, id := range requested {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// taskError prefixes the message of an API error with the task it concerns.
+func taskError(task types.Task, err error) error {
+	if apiErr, ok := err.(*apiError); ok {
+		return &apiError{apiErr.Status, fmt.Sprintf("Task %d: %s", task.ID, apiErr.Message)}
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	CategoryBacklog    = "backlog"
+	CategoryTodo       = "todo"
+	CategoryInProgress = "in-progress"
+	CategoryDone       = "done"
+)
+
+// categoryTimestamps sets started_at and completed_at from the category of
+// the task's current column at time $1. A task is started once it reaches
+// in-progress or done and completed once it reaches done; moving back clears
+// the timestamps it no longer has.
+const categoryTimestamps = `UPDATE tasks SET
+	started_at = CASE WHEN columns.category IN ('in-progress', 'done') THEN COALESCE(tasks.started_at, $1) END,
+	completed_at = CASE WHEN columns.category = 'done' THEN COALESCE(tasks.completed_at, $1) END
+	FROM columns WHERE columns.id = tasks.column_id`
+
+func isValidCategory(category string) bool {
+	switch category {
+	case CategoryBacklog, CategoryTodo, CategoryInProgress, CategoryDone:
+		return true
+	}
+	return false
+}
+
+// defaultCategory maps a column status to a category the same way the
+// category migration did for existing columns.
+func defaultCategory(status string) string {
+	switch status {
+	case "done":
+		return CategoryDone
+	case "doing", "in progress", "review", "verifying":
+		return CategoryInProgress
+	case "backlog", "new":
+		return CategoryBacklog
+	}
+	return CategoryTodo
+}
+
+func validateCategory(category *string, status string) error {
+	if *category == "" {
+		*category = defaultCategory(status)
+	}
+	if !isValidCategory(*category) {
+		return &apiError{http.StatusBadRequest, "category must be one of: backlog, todo, in-progress, done"}
+	}
+	return nil
+}
+
+// trackCategory updates the category timestamps of a task after it was
+// created in or moved to a column.
+func trackCategory(db querier, task *types.Task, now time.Time) error {
+	err := db.QueryRow(categoryTimestamps+" AND tasks.id = $2 RETURNING tasks.started_at, tasks.completed_at",
+		now, task.ID).Scan(&task.StartedAt, &task.CompletedAt)
+	if err != nil {
+		return err
+	}
+	if task.StartedAt != nil {
+		startedAt := task.StartedAt.UTC()
+		task.StartedAt = &startedAt
+	}
+	if task.CompletedAt != nil {
+		completedAt := task.CompletedAt.UTC()
+		task.CompletedAt = &completedAt
+	}
+	return nil
+}
+
+func (app *App) UpdateColumnCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	columnID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid column ID", http.StatusBadRequest)
+		return
+	}
+
+	var request types.ColumnCategory
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if !isValidCategory(request.Category) {
+		http.Error(w, "category must be one of: backlog, todo, in-progress, done", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE columns SET category = $1 WHERE id = $2 AND deleted_at IS NULL", request.Category, columnID)
+	if err != nil {
+		http.Error(w, "Error updating column category", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Column not found", http.StatusNotFound)
+		return
+	}
+
+	// The column's tasks cross categories along with it.
+	_, err = tx.Exec(categoryTimestamps+" AND tasks.column_id = $2", time.Now().UTC(), columnID)
+	if err != nil {
+		http.Error(w, "Error updating task timestamps", http.StatusInternalServerError)
+		return
+	}
+
+	var column types.Column
+	if err := scanColumn(tx.QueryRow("SELECT "+columnSelect+" FROM columns WHERE id = $1", columnID), &column); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Column not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching column", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating column category", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(column)
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const maxLabelLength = 50
+
+func normalizeLabels(labels []string) ([]string, error) {
+	normalized := make([]string, 0, len(labels))
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		if len(label) > maxLabelLength {
+			return nil, &apiError{http.StatusBadRequest, "Labels must be at most 50 characters"}
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+	return normalized, nil
+}
+
+func setTaskLabels(db querier, taskID int, labels []string) error {
+	_, err := db.Exec("DELETE FROM task_labels WHERE task_id = $1", taskID)
+	if err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	_, err = db.Exec("INSERT INTO task_labels (task_id, label) SELECT $1, UNNEST($2::text[]) ON CONFLICT DO NOTHING",
+		taskID, pq.Array(labels))
+	return err
+}
+
+func addChecklistItems(db querier, taskID int, items []string) error {
+	for _, content := range items {
+		content = strings.TrimSpace(content)
+		if content == "" {
+			continue
+		}
+		_, err := db.Exec(`INSERT INTO task_checklist_items (task_id, content, position)
+			SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM task_checklist_items WHERE task_id = $1`,
+			taskID, content)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (app *App) attachLabelsAndChecklists(tasks []types.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(tasks))
+	index := make(map[int]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = int64(task.ID)
+		index[task.ID] = i
+	}
+
+	labelRows, err := app.DB.Query("SELECT task_id, label FROM task_labels WHERE task_id = ANY($1) ORDER BY label", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer labelRows.Close()
+
+	for labelRows.Next() {
+		var taskID int
+		var label string
+		if err := labelRows.Scan(&taskID, &label); err != nil {
+			return err
+		}
+		tasks[index[taskID]].Labels = append(tasks[index[taskID]].Labels, label)
+	}
+	if err := labelRows.Err(); err != nil {
+		return err
+	}
+
+	itemRows, err := app.DB.Query(`SELECT id, task_id, content, done, position FROM task_checklist_items
+		WHERE task_id = ANY($1) ORDER BY position, id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item types.ChecklistItem
+		if err := itemRows.Scan(&item.ID, &item.TaskID, &item.Content, &item.Done, &item.Position); err != nil {
+			return err
+		}
+		tasks[index[item.TaskID]].Checklist = append(tasks[index[item.TaskID]].Checklist, item)
+	}
+	return itemRows.Err()
+}
+
+func (app *App) CreateChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var item types.ChecklistItem
+	err = json.NewDecoder(r.Body).Decode(&item)
+	if err != nil || strings.TrimSpace(item.Content) == "" {
+		http.Error(w, "Invalid request payload or missing content", http.StatusBadRequest)
+		return
+	}
+
+	var taskExists bool
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1 AND deleted_at IS NULL)", taskID).Scan(&taskExists)
+	if err != nil {
+		http.Error(w, "Database error while checking task", http.StatusInternalServerError)
+		return
+	}
+	if !taskExists {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`INSERT INTO task_checklist_items (task_id, content, position)
+		SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM task_checklist_items WHERE task_id = $1
+		RETURNING id, task_id, content, done, position`,
+		taskID, strings.TrimSpace(item.Content)).Scan(&item.ID, &item.TaskID, &item.Content, &item.Done, &item.Position)
+	if err != nil {
+		http.Error(w, "Error creating checklist item", http.StatusInternalServerError)
+		return
+	}
+
+	changes := recordChange(nil, "checklist_item", nil, item)
+	err = logTaskAction(tx, item.TaskID, app.optionalUserID(r), "checklist", "Checklist item added", changes...)
+	if err != nil {
+		http.Error(w, "Error logging checklist item", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating checklist item", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+func (app *App) UpdateChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	itemID := chi.URLParam(r, "id")
+	if itemID == "" {
+		http.Error(w, "Checklist item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var updateData struct {
+		Content string `json:"content"`
+		Done    *bool  `json:"done"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&updateData)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var item types.ChecklistItem
+	err = app.DB.QueryRow("SELECT id, task_id, content, done, position FROM task_checklist_items WHERE id = $1", itemID).
+		Scan(&item.ID, &item.TaskID, &item.Content, &item.Done, &item.Position)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Checklist item not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching checklist item", http.StatusInternalServerError)
+		return
+	}
+
+	previous := item
+	if content := strings.TrimSpace(updateData.Content); content != "" {
+		item.Content = content
+	}
+	if updateData.Done != nil {
+		item.Done = *updateData.Done
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE task_checklist_items SET content = $1, done = $2 WHERE id = $3", item.Content, item.Done, item.ID)
+	if err != nil {
+		http.Error(w, "Error updating checklist item", http.StatusInternalServerError)
+		return
+	}
+
+	changes := recordChange(nil, "checklist_item", previous, item)
+	err = logTaskAction(tx, item.TaskID, app.optionalUserID(r), "checklist", "Checklist item updated", changes...)
+	if err != nil {
+		http.Error(w, "Error logging checklist item update", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating checklist item", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+func (app *App) DeleteChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	itemID := chi.URLParam(r, "id")
+	if itemID == "" {
+		http.Error(w, "Checklist item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var item types.ChecklistItem
+	err = tx.QueryRow("DELETE FROM task_checklist_items WHERE id = $1 RETURNING id, task_id, content, done, position", itemID).
+		Scan(&item.ID, &item.TaskID, &item.Content, &item.Done, &item.Position)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Checklist item not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while deleting checklist item", http.StatusInternalServerError)
+		return
+	}
+
+	changes := recordChange(nil, "checklist_item", item, nil)
+	err = logTaskAction(tx, item.TaskID, app.optionalUserID(r), "checklist", "Checklist item deleted", changes...)
+	if err != nil {
+		http.Error(w, "Error logging checklist item deletion", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error while deleting checklist item", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Checklist item deleted successfully"))
+}
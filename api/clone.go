@@ -0,0 +1,622 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const (
+	taskTitleExists = `SELECT EXISTS(SELECT 1 FROM tasks WHERE title = $1 AND deleted_at IS NULL
+		AND column_id IN (SELECT columns.id FROM columns JOIN boards ON boards.id = columns.board_id WHERE boards.project_id = $2))`
+	boardNameExists   = "SELECT EXISTS(SELECT 1 FROM boards WHERE name = $1 AND deleted_at IS NULL)"
+	projectNameExists = "SELECT EXISTS(SELECT 1 FROM projects WHERE name = $1 AND deleted_at IS NULL)"
+
+	maxNameLength = 100
+)
+
+// copyName returns name suffixed with " (copy)", " (copy 2)", ... so that it
+// passes the same uniqueness check the create handlers apply. args follow the
+// candidate name as parameters of existsQuery.
+func copyName(db querier, existsQuery, name string, args ...any) (string, error) {
+	for i := 1; ; i++ {
+		suffix := " (copy)"
+		if i > 1 {
+			suffix = fmt.Sprintf(" (copy %d)", i)
+		}
+		base := []rune(name)
+		if limit := maxNameLength - len(suffix); len(base) > limit {
+			base = base[:limit]
+		}
+		candidate := string(base) + suffix
+
+		var exists bool
+		if err := db.QueryRow(existsQuery, append([]any{candidate}, args...)...).Scan(&exists); err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+}
+
+// requestedName uses the name given in a clone request if it is free, and a
+// generated copy name otherwise.
+func requestedName(db querier, existsQuery, requested, source string) (string, error) {
+	if requested == "" {
+		return copyName(db, existsQuery, source)
+	}
+	var exists bool
+	if err := db.QueryRow(existsQuery, requested).Scan(&exists); err != nil {
+		return "", err
+	}
+	if exists {
+		return "", &apiError{http.StatusConflict, fmt.Sprintf("%q already exists", requested)}
+	}
+	return requested, nil
+}
+
+// cloneTask copies an enriched task into the given column under title. Custom
+// values are carried over to fields of the same name in the target project;
+// values such a field does not accept are dropped.
+func cloneTask(db querier, source types.Task, columnID, projectID, actorID int, title string, options types.CloneRequest) (types.Task, error) {
+	task := types.Task{
+		ColumnID:       columnID,
+		Title:          title,
+		Description:    source.Description,
+		EstimatePoints: source.EstimatePoints,
+		EstimateHours:  source.EstimateHours,
+	}
+	if actorID != 0 {
+		task.CreatedBy = &actorID
+	}
+	if source.AssigneeID != nil {
+		var isMember bool
+		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM project_users WHERE project_id = $1 AND user_id = $2)",
+			projectID, *source.AssigneeID).Scan(&isMember)
+		if err != nil {
+			return task, err
+		}
+		if isMember {
+			task.AssigneeID = source.AssigneeID
+		}
+	}
+
+	number, key, err := nextTaskNumber(db, projectID)
+	if err != nil {
+		return task, err
+	}
+	task.Key = key
+
+	err = db.QueryRow(`INSERT INTO tasks (column_id, title, description, estimate_points, estimate_hours, created_by, assignee_id, number)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`,
+		task.ColumnID, task.Title, task.Description, task.EstimatePoints, task.EstimateHours,
+		task.CreatedBy, task.AssigneeID, number).Scan(&task.ID, &task.CreatedAt)
+	if err != nil {
+		return task, err
+	}
+	if err := storeDescriptionHTML(db, "tasks", task.ID, task.Description); err != nil {
+		return task, err
+	}
+	if err := trackCategory(db, &task, time.Now().UTC()); err != nil {
+		return task, err
+	}
+
+	carried, err := carryCustomValues(db, source, projectID)
+	if err != nil {
+		return task, err
+	}
+	customValues, err := validateCustomValues(db, projectID, carried, false)
+	if err != nil {
+		return task, err
+	}
+	var customChanges []types.FieldChange
+	for _, name := range slices.Sorted(maps.Keys(carried)) {
+		customChanges = recordChange(customChanges, customFieldPrefix+name, nil, carried[name])
+	}
+	if err := saveCustomValues(db, task.ID, customValues); err != nil {
+		return task, err
+	}
+
+	if options.IncludeLabels {
+		if err := setTaskLabels(db, task.ID, source.Labels); err != nil {
+			return task, err
+		}
+	}
+	if options.IncludeChecklist {
+		_, err := db.Exec(`INSERT INTO task_checklist_items (task_id, content, done, position)
+			SELECT $2, content, done, position FROM task_checklist_items WHERE task_id = $1`, source.ID, task.ID)
+		if err != nil {
+			return task, err
+		}
+	}
+
+	for _, watcherID := range []*int{task.CreatedBy, task.AssigneeID} {
+		if watcherID == nil {
+			continue
+		}
+		if err := addWatcher(db, task.ID, *watcherID); err != nil {
+			return task, err
+		}
+	}
+
+	var changes []types.FieldChange
+	changes = recordChange(changes, "title", nil, task.Title)
+	changes = recordChange(changes, "description", nil, task.Description)
+	changes = recordChange(changes, "column_id", nil, task.ColumnID)
+	changes = recordChange(changes, "estimate_points", nil, task.EstimatePoints)
+	changes = recordChange(changes, "estimate_hours", nil, task.EstimateHours)
+	changes = recordChange(changes, "assignee_id", nil, task.AssigneeID)
+	if options.IncludeLabels && len(source.Labels) > 0 {
+		changes = recordChange(changes, "labels", nil, source.Labels)
+	}
+	changes = append(changes, customChanges...)
+
+	return task, logTaskAction(db, task.ID, actorID, "create", "Task cloned from "+source.Key, changes...)
+}
+
+// cloneBoard copies a board with its columns into the project and, if
+// requested, its open tasks. Each cloned task is recorded in taskIDs for
+// linkClonedParents to restore the subtask structure.
+func (app *App) cloneBoard(db querier, source types.Board, projectID int, name string, actorID int, options types.CloneRequest,
+	taskIDs map[int]int) (types.Board, error) {
+	board := types.Board{ProjectID: projectID, Name: name}
+	err := db.QueryRow(`INSERT INTO boards (project_id, name, swimlane_field, labels)
+		SELECT $1, $2, swimlane_field, labels FROM boards WHERE id = $3 RETURNING id, swimlane_field, labels`,
+		projectID, name, source.ID).Scan(&board.ID, &board.SwimlaneField, pq.Array(&board.Labels))
+	if err != nil {
+		return board, err
+	}
+
+	sourceColumns, err := boardColumns(db, source.ID)
+	if err != nil {
+		return board, err
+	}
+
+	columnIDs := make(map[int]int, len(sourceColumns))
+	sourceColumnIDs := make([]int64, len(sourceColumns))
+	for i, column := range sourceColumns {
+		clone := types.Column{BoardID: board.ID, Status: column.Status, Category: column.Category, Position: column.Position,
+			WIPMin: column.WIPMin, WIPMax: column.WIPMax, WIPMode: column.WIPMode}
+		err := db.QueryRow(`INSERT INTO columns (board_id, status, category, position, wip_min, wip_max, wip_mode)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+			board.ID, column.Status, column.Category, column.Position, column.WIPMin, column.WIPMax, column.WIPMode).Scan(&clone.ID)
+		if err != nil {
+			return board, err
+		}
+		columnIDs[column.ID] = clone.ID
+		sourceColumnIDs[i] = int64(column.ID)
+		board.Columns = append(board.Columns, clone)
+	}
+
+	workflow, err := boardWorkflow(db, source.ID)
+	if err != nil {
+		return board, err
+	}
+	for _, transition := range workflow.Transitions {
+		fromID, fromOK := columnIDs[transition.FromColumnID]
+		toID, toOK := columnIDs[transition.ToColumnID]
+		if !fromOK || !toOK {
+			continue
+		}
+		_, err := db.Exec(`INSERT INTO workflow_transitions (board_id, from_column_id, to_column_id, roles, required_fields)
+			VALUES ($1, $2, $3, $4, $5)`, board.ID, fromID, toID, pq.Array(transition.Roles), pq.Array(transition.RequiredFields))
+		if err != nil {
+			return board, err
+		}
+	}
+
+	sourceSwimlanes, err := boardSwimlanes(db, source.ID)
+	if err != nil {
+		return board, err
+	}
+	swimlaneIDs := make(map[int]int, len(sourceSwimlanes))
+	for _, swimlane := range sourceSwimlanes {
+		var cloneID int
+		err := db.QueryRow("INSERT INTO swimlanes (board_id, name, position) VALUES ($1, $2, $3) RETURNING id",
+			board.ID, swimlane.Name, swimlane.Position).Scan(&cloneID)
+		if err != nil {
+			return board, err
+		}
+		swimlaneIDs[swimlane.ID] = cloneID
+	}
+
+	if !options.IncludeTasks || len(sourceColumnIDs) == 0 {
+		return board, nil
+	}
+
+	rows, err := db.Query("SELECT "+taskColumns+` FROM tasks
+		WHERE column_id = ANY($1) AND deleted_at IS NULL AND archived_at IS NULL ORDER BY id`, pq.Array(sourceColumnIDs))
+	if err != nil {
+		return board, err
+	}
+	var tasks []types.Task
+	for rows.Next() {
+		var task types.Task
+		if err := scanTask(rows, &task); err != nil {
+			rows.Close()
+			return board, err
+		}
+		tasks = append(tasks, task)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return board, err
+	}
+	if err := app.enrichTasks(tasks); err != nil {
+		return board, err
+	}
+
+	for _, task := range tasks {
+		clone, err := cloneTask(db, task, columnIDs[task.ColumnID], projectID, actorID, task.Title, options)
+		if err != nil {
+			return board, err
+		}
+		taskIDs[task.ID] = clone.ID
+
+		var swimlaneID *int
+		if task.SwimlaneID != nil {
+			id := swimlaneIDs[*task.SwimlaneID]
+			swimlaneID = &id
+		}
+		if swimlaneID != nil || task.Position != nil {
+			_, err := db.Exec("UPDATE tasks SET swimlane_id = $1, position = $2 WHERE id = $3", swimlaneID, task.Position, clone.ID)
+			if err != nil {
+				return board, err
+			}
+		}
+	}
+
+	return board, nil
+}
+
+// linkClonedParents links cloned tasks to the clones of their parents once
+// every task exists, since a parent may come after its children or sit on
+// another board of the same project. taskIDs maps source to clone IDs.
+func linkClonedParents(db querier, taskIDs map[int]int) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(taskIDs))
+	for id := range taskIDs {
+		ids = append(ids, int64(id))
+	}
+	rows, err := db.Query("SELECT id, parent_id FROM tasks WHERE id = ANY($1) AND parent_id IS NOT NULL", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	parents := make(map[int]int)
+	for rows.Next() {
+		var id, parentID int
+		if err := rows.Scan(&id, &parentID); err != nil {
+			rows.Close()
+			return err
+		}
+		parents[id] = parentID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, parentID := range parents {
+		if cloneParentID, ok := taskIDs[parentID]; ok {
+			if _, err := db.Exec("UPDATE tasks SET parent_id = $1 WHERE id = $2", cloneParentID, taskIDs[id]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (app *App) CloneTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+
+	var options types.CloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var source types.Task
+	err = scanTask(app.DB.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND deleted_at IS NULL", taskID), &source)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching task", http.StatusInternalServerError)
+		return
+	}
+	tasks := []types.Task{source}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+	source = tasks[0]
+
+	columnID := source.ColumnID
+	if options.ColumnID != 0 {
+		columnID = options.ColumnID
+	}
+	projectID, err := app.columnProjectID(columnID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Column does not exist", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while checking column", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	title := options.Name
+	if len([]rune(title)) > maxNameLength {
+		http.Error(w, "Title must be at most 100 characters", http.StatusBadRequest)
+		return
+	}
+	if title == "" {
+		title, err = copyName(tx, taskTitleExists, source.Title, projectID)
+		if err != nil {
+			http.Error(w, "Database error while naming task copy", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := checkEntry(tx, columnID); err != nil {
+		respondError(w, err, "Database error while checking workflow")
+		return
+	}
+	warning, err := admitToColumn(tx, columnID)
+	if err != nil {
+		respondError(w, err, "Database error while checking WIP limit")
+		return
+	}
+
+	clone, err := cloneTask(tx, source, columnID, projectID, app.optionalUserID(r), title, options)
+	if err != nil {
+		respondError(w, err, "Error cloning task")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error cloning task", http.StatusInternalServerError)
+		return
+	}
+
+	tasks = []types.Task{clone}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+	if warning != "" {
+		tasks[0].Warnings = []string{warning}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks[0])
+}
+
+func (app *App) CloneBoardHandler(w http.ResponseWriter, r *http.Request) {
+	boardID := chi.URLParam(r, "id")
+	if boardID == "" {
+		http.Error(w, "Board ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var options types.CloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var source types.Board
+	err := app.DB.QueryRow("SELECT id, project_id, name FROM boards WHERE id = $1 AND deleted_at IS NULL", boardID).
+		Scan(&source.ID, &source.ProjectID, &source.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Board not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching board", http.StatusInternalServerError)
+		return
+	}
+
+	projectID := source.ProjectID
+	if options.ProjectID != 0 {
+		projectID = options.ProjectID
+		var projectExists bool
+		err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1 AND deleted_at IS NULL)", projectID).Scan(&projectExists)
+		if err != nil {
+			http.Error(w, "Database error while checking project", http.StatusInternalServerError)
+			return
+		}
+		if !projectExists {
+			http.Error(w, "Project does not exist", http.StatusNotFound)
+			return
+		}
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	name, err := requestedName(tx, boardNameExists, options.Name, source.Name)
+	if err != nil {
+		respondError(w, err, "Database error while checking board name")
+		return
+	}
+
+	taskIDs := make(map[int]int)
+	board, err := app.cloneBoard(tx, source, projectID, name, app.optionalUserID(r), options, taskIDs)
+	if err != nil {
+		respondError(w, err, "Error cloning board")
+		return
+	}
+	if err := linkClonedParents(tx, taskIDs); err != nil {
+		http.Error(w, "Error linking cloned subtasks", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error cloning board", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+func (app *App) CloneProjectHandler(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	var options types.CloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var source types.Project
+	err = app.DB.QueryRow("SELECT id, key, name, description, user_id FROM projects WHERE id = $1 AND deleted_at IS NULL", projectID).
+		Scan(&source.ID, &source.Key, &source.Name, &source.Description, &source.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching project", http.StatusInternalServerError)
+		return
+	}
+
+	actorID := app.optionalUserID(r)
+	project := types.Project{Description: source.Description, UserID: source.UserID}
+	if actorID != 0 {
+		project.UserID = actorID
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	project.Name, err = requestedName(tx, projectNameExists, options.Name, source.Name)
+	if err != nil {
+		respondError(w, err, "Database error while checking project name")
+		return
+	}
+	project.Key, err = app.uniqueProjectKey(project.Name)
+	if err != nil {
+		http.Error(w, "Database error while generating project key", http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.QueryRow("INSERT INTO projects (name, user_id, description, key) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		project.Name, project.UserID, project.Description, project.Key).Scan(&project.ID, &project.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "This project key is already in use", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Error creating project", http.StatusInternalServerError)
+		return
+	}
+	if err := storeDescriptionHTML(tx, "projects", project.ID, project.Description); err != nil {
+		http.Error(w, "Error rendering project description", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = tx.Exec(`INSERT INTO project_users (project_id, user_id, role)
+		SELECT $2, user_id, role FROM project_users WHERE project_id = $1
+		UNION SELECT $2, $3, 'user'
+		ON CONFLICT DO NOTHING`, source.ID, project.ID, project.UserID)
+	if err != nil {
+		http.Error(w, "Error copying project members", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = tx.Exec(`INSERT INTO custom_fields (project_id, name, type, options, required)
+		SELECT $2, name, type, options, required FROM custom_fields WHERE project_id = $1`, source.ID, project.ID)
+	if err != nil {
+		http.Error(w, "Error copying custom fields", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := tx.Query("SELECT id, project_id, name FROM boards WHERE project_id = $1 AND deleted_at IS NULL ORDER BY id", source.ID)
+	if err != nil {
+		http.Error(w, "Database error while fetching boards", http.StatusInternalServerError)
+		return
+	}
+	var boards []types.Board
+	for rows.Next() {
+		var board types.Board
+		if err := rows.Scan(&board.ID, &board.ProjectID, &board.Name); err != nil {
+			rows.Close()
+			http.Error(w, "Error scanning boards", http.StatusInternalServerError)
+			return
+		}
+		boards = append(boards, board)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error scanning boards", http.StatusInternalServerError)
+		return
+	}
+
+	// Subtasks may sit on another board than their parent, so one map covers
+	// the whole project and parents are linked after every board is cloned.
+	taskIDs := make(map[int]int)
+	for _, board := range boards {
+		name, err := copyName(tx, boardNameExists, board.Name)
+		if err != nil {
+			http.Error(w, "Database error while checking board name", http.StatusInternalServerError)
+			return
+		}
+		clone, err := app.cloneBoard(tx, board, project.ID, name, actorID, options, taskIDs)
+		if err != nil {
+			respondError(w, err, "Error cloning board")
+			return
+		}
+		project.Boards = append(project.Boards, clone)
+	}
+	if err := linkClonedParents(tx, taskIDs); err != nil {
+		http.Error(w, "Error linking cloned subtasks", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error cloning project", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
@@ -5,10 +5,36 @@ import (
 	"encoding/json"
 	"kanban-board/types"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
 )
 
+// columnStatusExists checks for another live column with the status on the
+// same board, matching the unique_status_per_board index.
+const columnStatusExists = `SELECT EXISTS(SELECT 1 FROM columns
+	WHERE board_id = $1 AND status = $2 AND id <> $3 AND deleted_at IS NULL)`
+
+func boardColumns(db querier, boardID int) ([]types.Column, error) {
+	rows, err := db.Query("SELECT "+columnSelect+` FROM columns
+		WHERE board_id = $1 AND deleted_at IS NULL ORDER BY position, id`, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []types.Column{}
+	for rows.Next() {
+		var column types.Column
+		if err := scanColumn(rows, &column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
 func (app *App) CreateColumnHandler(w http.ResponseWriter, r *http.Request) {
 	var column types.Column
 
@@ -19,12 +45,26 @@ func (app *App) CreateColumnHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if column.BoardID == 0 {
-		http.Error(w, "UserID is required", http.StatusBadRequest)
+		http.Error(w, "BoardID is required", http.StatusBadRequest)
+		return
+	}
+	if column.Status == "" {
+		http.Error(w, "Status is required", http.StatusBadRequest)
+		return
+	}
+
+	limits := types.WIPLimits{WIPMin: column.WIPMin, WIPMax: column.WIPMax, WIPMode: column.WIPMode}
+	if err := validateWIPLimits(&limits); err != nil {
+		respondError(w, err, "Invalid WIP limits")
+		return
+	}
+	if err := validateCategory(&column.Category, column.Status); err != nil {
+		respondError(w, err, "Invalid column category")
 		return
 	}
 
 	var boardExists bool
-	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM boards WHERE id = $1)", column.BoardID).Scan(&boardExists)
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM boards WHERE id = $1 AND deleted_at IS NULL)", column.BoardID).Scan(&boardExists)
 	if err != nil {
 		http.Error(w, "Database error while checking board", http.StatusInternalServerError)
 		return
@@ -35,7 +75,7 @@ func (app *App) CreateColumnHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var existingColumnStatus bool
-	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM columns WHERE status = $1)", column.Status).Scan(&existingColumnStatus)
+	err = app.DB.QueryRow(columnStatusExists, column.BoardID, column.Status, 0).Scan(&existingColumnStatus)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -45,17 +85,29 @@ func (app *App) CreateColumnHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.DB.QueryRow("INSERT INTO columns (board_id, status) VALUES ($1, $2) RETURNING id",
-		column.BoardID, column.Status).Scan(&column.ID)
+	err = app.DB.QueryRow(`INSERT INTO columns (board_id, status, category, position, wip_min, wip_max, wip_mode)
+		VALUES ($1, $2, $3, (SELECT COALESCE(MAX(position) + 1, 0) FROM columns WHERE board_id = $1 AND deleted_at IS NULL), $4, $5, $6)
+		RETURNING id, position`,
+		column.BoardID, column.Status, column.Category, limits.WIPMin, limits.WIPMax, limits.WIPMode).Scan(&column.ID, &column.Position)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "This column already exists", http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error creating column", http.StatusInternalServerError)
 		return
 	}
 
 	response := types.Column{
-		ID:      column.ID,
-		BoardID: column.BoardID,
-		Status:  column.Status,
+		ID:        column.ID,
+		BoardID:   column.BoardID,
+		Status:    column.Status,
+		Category:  column.Category,
+		Position:  column.Position,
+		WIPMin:    limits.WIPMin,
+		WIPMax:    limits.WIPMax,
+		WIPMode:   limits.WIPMode,
+		WIPStatus: wipStatus(0, limits.WIPMin, limits.WIPMax),
 	}
 
 	w.Header().Set("Content-type", "application/json")
@@ -66,8 +118,7 @@ func (app *App) GetColumnByID(w http.ResponseWriter, r *http.Request) {
 	columnID := chi.URLParam(r, "id")
 	var column types.Column
 
-	err := app.DB.QueryRow("SELECT id, board_id, status FROM columns WHERE id = $1", columnID).
-		Scan(&column.ID, &column.BoardID, &column.Status)
+	err := scanColumn(app.DB.QueryRow("SELECT "+columnSelect+" FROM columns WHERE id = $1 AND deleted_at IS NULL", columnID), &column)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Column not found", http.StatusNotFound)
@@ -82,7 +133,7 @@ func (app *App) GetColumnByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *App) GetColumns(w http.ResponseWriter, r *http.Request) {
-	rows, err := app.DB.Query("SELECT id, board_id, status FROM columns")
+	rows, err := app.DB.Query("SELECT " + columnSelect + " FROM columns WHERE deleted_at IS NULL ORDER BY board_id, position, id")
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -92,7 +143,7 @@ func (app *App) GetColumns(w http.ResponseWriter, r *http.Request) {
 	var columns []types.Column
 	for rows.Next() {
 		var column types.Column
-		if err := rows.Scan(&column.ID, &column.BoardID, &column.Status); err != nil {
+		if err := scanColumn(rows, &column); err != nil {
 			http.Error(w, "Error scanning projects", http.StatusInternalServerError)
 			return
 		}
@@ -110,20 +161,19 @@ func (app *App) DeleteColumnHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := app.DB.Exec("DELETE FROM columns WHERE id = $1", columnID)
+	id, err := strconv.Atoi(columnID)
 	if err != nil {
-		http.Error(w, "Database error while deleting column", http.StatusInternalServerError)
+		http.Error(w, "Invalid column ID", http.StatusBadRequest)
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	err = app.moveToTrash(r, "column", id)
 	if err != nil {
-		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
-		return
-	}
-
-	if rowsAffected == 0 {
-		http.Error(w, "Column not found", http.StatusNotFound)
+		if apiErr, ok := err.(*apiError); ok && apiErr.Status == http.StatusNotFound {
+			http.Error(w, "Column not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while deleting column", http.StatusInternalServerError)
 		return
 	}
 
@@ -145,9 +195,14 @@ func (app *App) UpdateColumnHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if updateData.Status == "" {
+		http.Error(w, "Status is required", http.StatusBadRequest)
+		return
+	}
+
 	var existingColumn types.Column
-	err = app.DB.QueryRow("SELECT id, board_id, status FROM columns WHERE id = $1",
-		columnID).Scan(&existingColumn.ID, &existingColumn.BoardID, &existingColumn.Status)
+	err = scanColumn(app.DB.QueryRow("SELECT "+columnSelect+" FROM columns WHERE id = $1 AND deleted_at IS NULL", columnID),
+		&existingColumn)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Column not found", http.StatusNotFound)
@@ -157,18 +212,140 @@ func (app *App) UpdateColumnHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var existingColumnStatus bool
+	err = app.DB.QueryRow(columnStatusExists, existingColumn.BoardID, updateData.Status, existingColumn.ID).Scan(&existingColumnStatus)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if existingColumnStatus {
+		http.Error(w, "This column already exists", http.StatusConflict)
+		return
+	}
+
 	_, err = app.DB.Exec("UPDATE columns SET status = $1 WHERE id = $2", updateData.Status, columnID)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "This column already exists", http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error updating board name", http.StatusInternalServerError)
 		return
 	}
 
-	response := types.Column{
-		ID:      existingColumn.ID,
-		BoardID: existingColumn.BoardID,
-		Status:  updateData.Status,
-	}
+	response := existingColumn
+	response.Status = updateData.Status
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+func (app *App) GetBoardColumns(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+
+	var boardExists bool
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM boards WHERE id = $1 AND deleted_at IS NULL)", boardID).Scan(&boardExists)
+	if err != nil {
+		http.Error(w, "Database error while checking board", http.StatusInternalServerError)
+		return
+	}
+	if !boardExists {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return
+	}
+
+	columns, err := boardColumns(app.DB, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching columns", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(columns)
+}
+
+// ReorderColumnsHandler sets the order of a board's columns from the full
+// list of its column IDs.
+func (app *App) ReorderColumnsHandler(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+
+	var order types.ColumnOrder
+	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var boardExists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM boards WHERE id = $1 AND deleted_at IS NULL)", boardID).Scan(&boardExists)
+	if err != nil {
+		http.Error(w, "Database error while checking board", http.StatusInternalServerError)
+		return
+	}
+	if !boardExists {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return
+	}
+
+	_, err = tx.Exec("SELECT id FROM columns WHERE board_id = $1 AND deleted_at IS NULL FOR UPDATE", boardID)
+	if err != nil {
+		http.Error(w, "Database error while locking columns", http.StatusInternalServerError)
+		return
+	}
+	columns, err := boardColumns(tx, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching columns", http.StatusInternalServerError)
+		return
+	}
+
+	onBoard := make(map[int]bool, len(columns))
+	for _, column := range columns {
+		onBoard[column.ID] = true
+	}
+	seen := make(map[int]bool, len(order.ColumnIDs))
+	for _, columnID := range order.ColumnIDs {
+		if !onBoard[columnID] || seen[columnID] {
+			http.Error(w, "column_ids must list each column of the board exactly once", http.StatusBadRequest)
+			return
+		}
+		seen[columnID] = true
+	}
+	if len(seen) != len(columns) {
+		http.Error(w, "column_ids must list each column of the board exactly once", http.StatusBadRequest)
+		return
+	}
+
+	for position, columnID := range order.ColumnIDs {
+		if _, err := tx.Exec("UPDATE columns SET position = $1 WHERE id = $2", position, columnID); err != nil {
+			http.Error(w, "Error reordering columns", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	columns, err = boardColumns(tx, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching columns", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error reordering columns", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(columns)
+}
@@ -0,0 +1,484 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const (
+	FieldText         = "text"
+	FieldNumber       = "number"
+	FieldDate         = "date"
+	FieldSingleSelect = "single_select"
+	FieldMultiSelect  = "multi_select"
+	FieldUser         = "user"
+)
+
+const customFieldPrefix = "cf."
+
+func isValidFieldType(fieldType string) bool {
+	switch fieldType {
+	case FieldText, FieldNumber, FieldDate, FieldSingleSelect, FieldMultiSelect, FieldUser:
+		return true
+	}
+	return false
+}
+
+func validateFieldDefinition(field types.CustomField) error {
+	if strings.TrimSpace(field.Name) == "" {
+		return &apiError{http.StatusBadRequest, "Field name is required"}
+	}
+	if !isValidFieldType(field.Type) {
+		return &apiError{http.StatusBadRequest, "Field type must be one of: text, number, date, single_select, multi_select, user"}
+	}
+	isSelect := field.Type == FieldSingleSelect || field.Type == FieldMultiSelect
+	if isSelect && len(field.Options) == 0 {
+		return &apiError{http.StatusBadRequest, "Select fields require at least one option"}
+	}
+	if !isSelect && len(field.Options) > 0 {
+		return &apiError{http.StatusBadRequest, "Only select fields can have options"}
+	}
+	return nil
+}
+
+func scanCustomField(row scanner, field *types.CustomField) error {
+	return row.Scan(&field.ID, &field.ProjectID, &field.Name, &field.Type, pq.Array(&field.Options), &field.Required, &field.CreatedAt)
+}
+
+const customFieldColumns = "id, project_id, name, type, options, required, created_at"
+
+func (app *App) CreateCustomFieldHandler(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	var field types.CustomField
+	err = json.NewDecoder(r.Body).Decode(&field)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateFieldDefinition(field); err != nil {
+		respondError(w, err, "Invalid field definition")
+		return
+	}
+	if field.Options == nil {
+		field.Options = []string{}
+	}
+
+	var projectExists bool
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1 AND deleted_at IS NULL)", projectID).Scan(&projectExists)
+	if err != nil {
+		http.Error(w, "Database error while checking project", http.StatusInternalServerError)
+		return
+	}
+	if !projectExists {
+		http.Error(w, "Project does not exist", http.StatusNotFound)
+		return
+	}
+
+	var fieldExists bool
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM custom_fields WHERE project_id = $1 AND name = $2)",
+		projectID, field.Name).Scan(&fieldExists)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if fieldExists {
+		http.Error(w, "This field already exists", http.StatusConflict)
+		return
+	}
+
+	err = scanCustomField(app.DB.QueryRow(`INSERT INTO custom_fields (project_id, name, type, options, required)
+		VALUES ($1, $2, $3, $4, $5) RETURNING `+customFieldColumns,
+		projectID, field.Name, field.Type, pq.Array(field.Options), field.Required), &field)
+	if err != nil {
+		http.Error(w, "Error creating custom field", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(field)
+}
+
+func (app *App) GetCustomFields(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if projectID == "" {
+		http.Error(w, "Project ID is required", http.StatusBadRequest)
+		return
+	}
+
+	fields, err := projectCustomFields(app.DB, projectID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fields)
+}
+
+func (app *App) UpdateCustomFieldHandler(w http.ResponseWriter, r *http.Request) {
+	fieldID := chi.URLParam(r, "id")
+	if fieldID == "" {
+		http.Error(w, "Field ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var updateData struct {
+		Name     string   `json:"name"`
+		Type     string   `json:"type"`
+		Options  []string `json:"options"`
+		Required *bool    `json:"required"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&updateData)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var field types.CustomField
+	err = scanCustomField(app.DB.QueryRow("SELECT "+customFieldColumns+" FROM custom_fields WHERE id = $1", fieldID), &field)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Custom field not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching custom field", http.StatusInternalServerError)
+		return
+	}
+
+	if updateData.Type != "" && updateData.Type != field.Type {
+		http.Error(w, "Field type cannot be changed", http.StatusBadRequest)
+		return
+	}
+	previousName := field.Name
+	if updateData.Name != "" {
+		field.Name = updateData.Name
+	}
+	if updateData.Options != nil {
+		field.Options = updateData.Options
+	}
+	if updateData.Required != nil {
+		field.Required = *updateData.Required
+	}
+
+	if err := validateFieldDefinition(field); err != nil {
+		respondError(w, err, "Invalid field definition")
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE custom_fields SET name = $1, options = $2, required = $3 WHERE id = $4",
+		field.Name, pq.Array(field.Options), field.Required, field.ID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "This field already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Error updating custom field", http.StatusInternalServerError)
+		return
+	}
+	if field.Name != previousName {
+		_, err = tx.Exec(`UPDATE workflow_transitions SET required_fields = array_replace(required_fields, $1, $2)
+			WHERE board_id IN (SELECT id FROM boards WHERE project_id = $3)`,
+			customFieldPrefix+previousName, customFieldPrefix+field.Name, field.ProjectID)
+		if err != nil {
+			http.Error(w, "Error updating workflow transitions", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating custom field", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(field)
+}
+
+func (app *App) DeleteCustomFieldHandler(w http.ResponseWriter, r *http.Request) {
+	fieldID := chi.URLParam(r, "id")
+	if fieldID == "" {
+		http.Error(w, "Field ID is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var projectID int
+	var name string
+	err = tx.QueryRow("DELETE FROM custom_fields WHERE id = $1 RETURNING project_id, name", fieldID).Scan(&projectID, &name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Custom field not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while deleting custom field", http.StatusInternalServerError)
+		return
+	}
+
+	// Transitions can no longer require a field that does not exist.
+	_, err = tx.Exec(`UPDATE workflow_transitions SET required_fields = array_remove(required_fields, $1)
+		WHERE board_id IN (SELECT id FROM boards WHERE project_id = $2)`, customFieldPrefix+name, projectID)
+	if err != nil {
+		http.Error(w, "Error updating workflow transitions", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error while deleting custom field", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Custom field deleted successfully"))
+}
+
+func projectCustomFields(db querier, projectID any) ([]types.CustomField, error) {
+	rows, err := db.Query("SELECT "+customFieldColumns+" FROM custom_fields WHERE project_id = $1 ORDER BY id", projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields []types.CustomField
+	for rows.Next() {
+		var field types.CustomField
+		if err := scanCustomField(rows, &field); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, rows.Err()
+}
+
+// validateCustomValues checks submitted values against the project's field
+// definitions and returns them keyed by field ID, with nil marking a cleared value.
+func validateCustomValues(db querier, projectID int, values map[string]any, creating bool) (map[int][]byte, error) {
+	fields, err := projectCustomFields(db, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]types.CustomField, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+		if _, ok := values[field.Name]; creating && field.Required && !ok {
+			return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("Custom field %q is required", field.Name)}
+		}
+	}
+
+	validated := make(map[int][]byte, len(values))
+	for name, value := range values {
+		field, ok := byName[name]
+		if !ok {
+			return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("Unknown custom field %q", name)}
+		}
+		if value == nil {
+			if field.Required {
+				return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("Custom field %q is required", field.Name)}
+			}
+			validated[field.ID] = nil
+			continue
+		}
+		if err := checkCustomValue(db, projectID, field, value); err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		validated[field.ID] = encoded
+	}
+	return validated, nil
+}
+
+func checkCustomValue(db querier, projectID int, field types.CustomField, value any) error {
+	invalid := &apiError{http.StatusBadRequest, fmt.Sprintf("Invalid value for %s field %q", field.Type, field.Name)}
+
+	switch field.Type {
+	case FieldText:
+		if _, ok := value.(string); !ok {
+			return invalid
+		}
+	case FieldNumber:
+		if _, ok := value.(float64); !ok {
+			return invalid
+		}
+	case FieldDate:
+		text, ok := value.(string)
+		if !ok {
+			return invalid
+		}
+		if _, err := time.Parse(dateLayout, text); err != nil {
+			return invalid
+		}
+	case FieldSingleSelect:
+		text, ok := value.(string)
+		if !ok || !slices.Contains(field.Options, text) {
+			return invalid
+		}
+	case FieldMultiSelect:
+		items, ok := value.([]any)
+		if !ok {
+			return invalid
+		}
+		for _, item := range items {
+			text, ok := item.(string)
+			if !ok || !slices.Contains(field.Options, text) {
+				return invalid
+			}
+		}
+	case FieldUser:
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return invalid
+		}
+		var isMember bool
+		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM project_users WHERE project_id = $1 AND user_id = $2)",
+			projectID, int(number)).Scan(&isMember)
+		if err != nil {
+			return err
+		}
+		if !isMember {
+			return invalid
+		}
+	}
+	return nil
+}
+
+// carryCustomValues returns, keyed by name, the custom values of a task that
+// the fields of the same name in another project accept. Other values are dropped.
+func carryCustomValues(db querier, task types.Task, projectID int) (map[string]any, error) {
+	fields, err := projectCustomFields(db, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	carried := make(map[string]any)
+	for _, field := range fields {
+		value, ok := task.CustomFields[field.Name]
+		if !ok || value == nil {
+			continue
+		}
+		if err := checkCustomValue(db, projectID, field, value); err != nil {
+			if _, ok := err.(*apiError); ok {
+				continue
+			}
+			return nil, err
+		}
+		carried[field.Name] = value
+	}
+	return carried, nil
+}
+
+func saveCustomValues(db querier, taskID int, values map[int][]byte) error {
+	for fieldID, value := range values {
+		var err error
+		if value == nil {
+			_, err = db.Exec("DELETE FROM task_custom_values WHERE task_id = $1 AND field_id = $2", taskID, fieldID)
+		} else {
+			_, err = db.Exec(`INSERT INTO task_custom_values (task_id, field_id, value) VALUES ($1, $2, $3)
+				ON CONFLICT (task_id, field_id) DO UPDATE SET value = EXCLUDED.value`, taskID, fieldID, string(value))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (app *App) attachCustomValues(tasks []types.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(tasks))
+	index := make(map[int]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = int64(task.ID)
+		index[task.ID] = i
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT task_custom_values.task_id, custom_fields.name, task_custom_values.value
+		FROM task_custom_values
+		JOIN custom_fields ON custom_fields.id = task_custom_values.field_id
+		WHERE task_custom_values.task_id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int
+		var name string
+		var raw []byte
+		if err := rows.Scan(&taskID, &name, &raw); err != nil {
+			return err
+		}
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		task := &tasks[index[taskID]]
+		if task.CustomFields == nil {
+			task.CustomFields = make(map[string]any)
+		}
+		task.CustomFields[name] = value
+	}
+	return rows.Err()
+}
+
+// customFieldFilters turns ?cf.<name>=<value> query parameters into task conditions.
+// Multi-select values match when the submitted option is one of the selected ones.
+func customFieldFilters(r *http.Request, conditions []string, args []any) ([]string, []any) {
+	for key, values := range r.URL.Query() {
+		name, ok := strings.CutPrefix(key, customFieldPrefix)
+		if !ok || name == "" {
+			continue
+		}
+		for _, value := range values {
+			args = append(args, name, value)
+			conditions = append(conditions, fmt.Sprintf(`EXISTS(SELECT 1 FROM task_custom_values
+				JOIN custom_fields ON custom_fields.id = task_custom_values.field_id
+				WHERE task_custom_values.task_id = tasks.id AND custom_fields.name = $%d
+				AND (task_custom_values.value #>> '{}' = $%d
+					OR (jsonb_typeof(task_custom_values.value) = 'array' AND task_custom_values.value ? $%d)))`,
+				len(args)-1, len(args), len(args)))
+		}
+	}
+	return conditions, args
+}
+
+func customFieldOrder(name string, args []any) (string, []any) {
+	args = append(args, name)
+	return fmt.Sprintf(`(SELECT task_custom_values.value FROM task_custom_values
+		JOIN custom_fields ON custom_fields.id = task_custom_values.field_id
+		WHERE task_custom_values.task_id = tasks.id AND custom_fields.name = $%d)`, len(args)), args
+}
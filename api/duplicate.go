@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	duplicateThreshold = 0.4
+	duplicateLimit     = 5
+	maxSimilarLimit    = 50
+)
+
+// similarTasks returns the tasks of a project whose titles are trigram-similar
+// to title, most similar first. excludeID leaves out the task being compared.
+func (app *App) similarTasks(projectID int, title string, excludeID, limit int) ([]types.SimilarTask, error) {
+	rows, err := app.DB.Query(`SELECT id, `+taskKeyExpr+`, title, column_id, similarity(title, $2) AS score
+		FROM tasks
+		WHERE deleted_at IS NULL AND id <> $3
+		AND column_id IN (SELECT columns.id FROM columns
+			JOIN boards ON boards.id = columns.board_id WHERE boards.project_id = $1)
+		AND title % $2 AND similarity(title, $2) >= $4
+		ORDER BY score DESC, id LIMIT $5`, projectID, title, excludeID, duplicateThreshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	similar := []types.SimilarTask{}
+	for rows.Next() {
+		var task types.SimilarTask
+		if err := rows.Scan(&task.ID, &task.Key, &task.Title, &task.ColumnID, &task.Similarity); err != nil {
+			return nil, err
+		}
+		similar = append(similar, task)
+	}
+	return similar, rows.Err()
+}
+
+// checkDuplicates rejects a new task with 409 and the likely duplicates,
+// unless the client overrides the warning with ?allow_duplicates=true.
+func (app *App) checkDuplicates(w http.ResponseWriter, r *http.Request, projectID int, title string) bool {
+	if r.URL.Query().Get("allow_duplicates") == "true" {
+		return true
+	}
+
+	similar, err := app.similarTasks(projectID, title, 0, duplicateLimit)
+	if err != nil {
+		http.Error(w, "Database error while checking for duplicates", http.StatusInternalServerError)
+		return false
+	}
+	if len(similar) == 0 {
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(types.DuplicateWarning{
+		Message: "Similar tasks already exist; retry with allow_duplicates=true to create it anyway",
+		Similar: similar,
+	})
+	return false
+}
+
+func similarLimit(r *http.Request) (int, error) {
+	limit := duplicateLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSimilarLimit {
+			return 0, &apiError{http.StatusBadRequest, "limit must be between 1 and 50"}
+		}
+		limit = parsed
+	}
+	return limit, nil
+}
+
+func (app *App) GetSimilarTasks(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+	limit, err := similarLimit(r)
+	if err != nil {
+		respondError(w, err, "Invalid limit")
+		return
+	}
+
+	projectID, err := app.taskProjectID(taskID)
+	if err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+
+	var title string
+	if err := app.DB.QueryRow("SELECT title FROM tasks WHERE id = $1", taskID).Scan(&title); err != nil {
+		http.Error(w, "Database error while fetching task", http.StatusInternalServerError)
+		return
+	}
+
+	similar, err := app.similarTasks(projectID, title, taskID, limit)
+	if err != nil {
+		http.Error(w, "Database error while finding similar tasks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(similar)
+}
+
+func (app *App) GetProjectSimilarTasks(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+	title := strings.TrimSpace(r.URL.Query().Get("title"))
+	if title == "" {
+		http.Error(w, "title is required", http.StatusBadRequest)
+		return
+	}
+	limit, err := similarLimit(r)
+	if err != nil {
+		respondError(w, err, "Invalid limit")
+		return
+	}
+
+	similar, err := app.similarTasks(projectID, title, 0, limit)
+	if err != nil {
+		http.Error(w, "Database error while finding similar tasks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(similar)
+}
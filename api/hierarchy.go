@@ -0,0 +1,312 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const maxTaskDepth = 5
+
+func (app *App) columnProjectID(columnID int) (int, error) {
+	var projectID int
+	err := app.DB.QueryRow(`SELECT boards.project_id FROM columns
+		JOIN boards ON boards.id = columns.board_id
+		WHERE columns.id = $1 AND columns.deleted_at IS NULL`, columnID).Scan(&projectID)
+	return projectID, err
+}
+
+func (app *App) checkParent(childID, childColumnID, parentID int) error {
+	if parentID == childID {
+		return &apiError{http.StatusBadRequest, "A task cannot be its own parent"}
+	}
+
+	var parentColumnID int
+	err := app.DB.QueryRow("SELECT column_id FROM tasks WHERE id = $1 AND deleted_at IS NULL", parentID).Scan(&parentColumnID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &apiError{http.StatusNotFound, "Parent task does not exist"}
+		}
+		return err
+	}
+
+	childProjectID, err := app.columnProjectID(childColumnID)
+	if err != nil {
+		return err
+	}
+	parentProjectID, err := app.columnProjectID(parentColumnID)
+	if err != nil {
+		return err
+	}
+	if childProjectID != parentProjectID {
+		return &apiError{http.StatusBadRequest, "Parent task must belong to the same project"}
+	}
+
+	var parentDepth int
+	err = app.DB.QueryRow(`
+		WITH RECURSIVE ancestors(id, parent_id, depth) AS (
+			SELECT id, parent_id, 1 FROM tasks WHERE id = $1
+			UNION ALL
+			SELECT tasks.id, tasks.parent_id, ancestors.depth + 1 FROM tasks
+			JOIN ancestors ON tasks.id = ancestors.parent_id
+		)
+		SELECT MAX(depth) FROM ancestors`, parentID).Scan(&parentDepth)
+	if err != nil {
+		return err
+	}
+
+	subtreeHeight := 1
+	if childID != 0 {
+		var parentIsDescendant bool
+		err = app.DB.QueryRow(`
+			WITH RECURSIVE descendants(id, depth) AS (
+				SELECT id, 1 FROM tasks WHERE id = $1
+				UNION ALL
+				SELECT tasks.id, descendants.depth + 1 FROM tasks
+				JOIN descendants ON tasks.parent_id = descendants.id
+			)
+			SELECT MAX(depth), COALESCE(BOOL_OR(id = $2), false) FROM descendants`,
+			childID, parentID).Scan(&subtreeHeight, &parentIsDescendant)
+		if err != nil {
+			return err
+		}
+		if parentIsDescendant {
+			return &apiError{http.StatusConflict, "This parent would create a hierarchy cycle"}
+		}
+	}
+
+	if parentDepth+subtreeHeight > maxTaskDepth {
+		return &apiError{http.StatusConflict, "Task hierarchy is too deep"}
+	}
+
+	return nil
+}
+
+// detachHierarchy unlinks a task moving to another project from its parent
+// and children, since a parent must be in the same project. The children's
+// changes are logged here; the task's own change is returned for its move log.
+func detachHierarchy(db querier, task types.Task, actorID int) ([]types.FieldChange, error) {
+	var changes []types.FieldChange
+	if task.ParentID != nil {
+		if _, err := db.Exec("UPDATE tasks SET parent_id = NULL WHERE id = $1", task.ID); err != nil {
+			return nil, err
+		}
+		changes = recordChange(changes, "parent_id", task.ParentID, nil)
+	}
+
+	rows, err := db.Query("UPDATE tasks SET parent_id = NULL WHERE parent_id = $1 RETURNING id", task.ID)
+	if err != nil {
+		return nil, err
+	}
+	var childIDs []int
+	for rows.Next() {
+		var childID int
+		if err := rows.Scan(&childID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		childIDs = append(childIDs, childID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, childID := range childIDs {
+		childChanges := recordChange(nil, "parent_id", task.ID, nil)
+		if err := logTaskAction(db, childID, actorID, "parent", "Parent task moved to another project", childChanges...); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+func (app *App) SetTaskParentHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	if taskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var parentData struct {
+		ParentID *int `json:"parent_id"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&parentData)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var task types.Task
+	err = scanTask(app.DB.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND deleted_at IS NULL", taskID), &task)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching task", http.StatusInternalServerError)
+		return
+	}
+
+	if parentData.ParentID != nil {
+		if err := app.checkParent(task.ID, task.ColumnID, *parentData.ParentID); err != nil {
+			respondError(w, err, "Database error while checking parent task")
+			return
+		}
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE tasks SET parent_id = $1 WHERE id = $2", parentData.ParentID, task.ID)
+	if err != nil {
+		http.Error(w, "Error updating task parent", http.StatusInternalServerError)
+		return
+	}
+	changes := recordChange(nil, "parent_id", task.ParentID, parentData.ParentID)
+	task.ParentID = parentData.ParentID
+
+	err = logTaskAction(tx, task.ID, app.optionalUserID(r), "parent", "Task parent updated", changes...)
+	if err != nil {
+		http.Error(w, "Error logging task parent update", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating task parent", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+func (app *App) GetTaskTree(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	if taskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		WITH RECURSIVE tree(id) AS (
+			SELECT id FROM tasks WHERE id = $1 AND deleted_at IS NULL
+			UNION ALL
+			SELECT tasks.id FROM tasks JOIN tree ON tasks.parent_id = tree.id
+			WHERE tasks.deleted_at IS NULL
+		)
+		SELECT `+taskColumns+`, (SELECT category FROM columns WHERE columns.id = tasks.column_id)
+		FROM tasks WHERE id IN (SELECT id FROM tree) ORDER BY id`, taskID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	nodes := make(map[int]*types.Task)
+	categories := make(map[int]string)
+	var order []int
+	for rows.Next() {
+		var task types.Task
+		var category string
+		if err := scanTask(rows, &task, &category); err != nil {
+			http.Error(w, "Error scanning tasks", http.StatusInternalServerError)
+			return
+		}
+		nodes[task.ID] = &task
+		categories[task.ID] = category
+		order = append(order, task.ID)
+	}
+	if err = rows.Err(); err != nil {
+		http.Error(w, "Database error after fetching tasks", http.StatusInternalServerError)
+		return
+	}
+	if len(order) == 0 {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	rootID := 0
+	childIDs := make(map[int][]int)
+	for _, id := range order {
+		parentID := nodes[id].ParentID
+		if parentID == nil || nodes[*parentID] == nil {
+			rootID = id
+			continue
+		}
+		childIDs[*parentID] = append(childIDs[*parentID], id)
+	}
+
+	var build func(id int) (types.Task, types.Progress)
+	build = func(id int) (types.Task, types.Progress) {
+		task := *nodes[id]
+		var progress types.Progress
+		for _, childID := range childIDs[id] {
+			child, childProgress := build(childID)
+			task.Children = append(task.Children, child)
+			progress.Total += childProgress.Total + 1
+			progress.Done += childProgress.Done
+			if categories[childID] == CategoryDone {
+				progress.Done++
+			}
+		}
+		if progress.Total > 0 {
+			task.Progress = &types.Progress{Done: progress.Done, Total: progress.Total}
+		}
+		return task, progress
+	}
+
+	root, _ := build(rootID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(root)
+}
+
+func (app *App) attachProgress(tasks []types.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(tasks))
+	index := make(map[int]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = int64(task.ID)
+		index[task.ID] = i
+	}
+
+	rows, err := app.DB.Query(`
+		WITH RECURSIVE descendants(root_id, id) AS (
+			SELECT parent_id, id FROM tasks WHERE parent_id = ANY($1) AND deleted_at IS NULL
+			UNION ALL
+			SELECT descendants.root_id, tasks.id FROM tasks
+			JOIN descendants ON tasks.parent_id = descendants.id
+			WHERE tasks.deleted_at IS NULL
+		)
+		SELECT descendants.root_id, COUNT(*), COUNT(*) FILTER (WHERE columns.category = $2)
+		FROM descendants
+		JOIN tasks ON tasks.id = descendants.id
+		JOIN columns ON columns.id = tasks.column_id
+		GROUP BY descendants.root_id`, pq.Array(ids), CategoryDone)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rootID int
+		var progress types.Progress
+		if err := rows.Scan(&rootID, &progress.Total, &progress.Done); err != nil {
+			return err
+		}
+		tasks[index[rootID]].Progress = &progress
+	}
+	return rows.Err()
+}
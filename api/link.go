@@ -0,0 +1,449 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kanban-board/types"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const (
+	LinkURL         = "url"
+	LinkPullRequest = "pull_request"
+	LinkCommit      = "commit"
+	LinkDocument    = "document"
+)
+
+const maxGitEventSize = 1 << 20
+
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+func isValidLinkType(linkType string) bool {
+	switch linkType {
+	case LinkURL, LinkPullRequest, LinkCommit, LinkDocument:
+		return true
+	}
+	return false
+}
+
+func isValidLinkURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+const linkColumns = "id, task_id, type, url, title, metadata, created_by, created_at"
+
+func scanLink(row scanner, link *types.TaskLink) error {
+	var metadata []byte
+	if err := row.Scan(&link.ID, &link.TaskID, &link.Type, &link.URL, &link.Title, &metadata,
+		&link.CreatedBy, &link.CreatedAt); err != nil {
+		return err
+	}
+	link.CreatedAt = link.CreatedAt.UTC()
+	return json.Unmarshal(metadata, &link.Metadata)
+}
+
+func (app *App) taskLinks(taskID int) ([]types.TaskLink, error) {
+	rows, err := app.DB.Query("SELECT "+linkColumns+" FROM task_links WHERE task_id = $1 ORDER BY created_at, id", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []types.TaskLink{}
+	for rows.Next() {
+		var link types.TaskLink
+		if err := scanLink(rows, &link); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+func (app *App) GetTaskLinks(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+	if _, err := app.taskProjectID(taskID); err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+
+	links, err := app.taskLinks(taskID)
+	if err != nil {
+		http.Error(w, "Database error while fetching links", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+func (app *App) CreateTaskLinkHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+
+	var link types.TaskLink
+	err = json.NewDecoder(r.Body).Decode(&link)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if !isValidLinkType(link.Type) {
+		http.Error(w, "Link type must be one of: url, pull_request, commit, document", http.StatusBadRequest)
+		return
+	}
+	link.URL = strings.TrimSpace(link.URL)
+	if !isValidLinkURL(link.URL) {
+		http.Error(w, "Link URL must be an absolute http or https URL", http.StatusBadRequest)
+		return
+	}
+	link.Title = strings.TrimSpace(link.Title)
+	if len(link.Title) > 255 {
+		http.Error(w, "Link title must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+	if link.Metadata == nil {
+		link.Metadata = map[string]any{}
+	}
+
+	if _, err := app.taskProjectID(taskID); err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	actorID := app.optionalUserID(r)
+	var createdBy *int
+	if actorID != 0 {
+		createdBy = &actorID
+	}
+	metadata, _ := json.Marshal(link.Metadata)
+
+	link.TaskID = taskID
+	link.CreatedBy = createdBy
+	err = tx.QueryRow(`INSERT INTO task_links (task_id, type, url, title, metadata, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		taskID, link.Type, link.URL, link.Title, metadata, createdBy).Scan(&link.ID, &link.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "This link already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Error creating link", http.StatusInternalServerError)
+		return
+	}
+	link.CreatedAt = link.CreatedAt.UTC()
+
+	err = logTaskAction(tx, taskID, actorID, "link", "Link added: "+link.URL,
+		recordChange(nil, "links", nil, link.URL)...)
+	if err != nil {
+		http.Error(w, "Error logging link", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+func (app *App) DeleteTaskLinkHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+	linkID, err := strconv.Atoi(chi.URLParam(r, "linkID"))
+	if err != nil {
+		http.Error(w, "Invalid link ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var linkURL string
+	err = tx.QueryRow("DELETE FROM task_links WHERE id = $1 AND task_id = $2 RETURNING url", linkID, taskID).Scan(&linkURL)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Link not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while deleting link", http.StatusInternalServerError)
+		return
+	}
+
+	err = logTaskAction(tx, taskID, app.optionalUserID(r), "link", "Link removed: "+linkURL,
+		recordChange(nil, "links", linkURL, nil)...)
+	if err != nil {
+		http.Error(w, "Error logging link", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error deleting link", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Link deleted successfully"))
+}
+
+// validGitSignature checks the "sha256=<hex>" HMAC of the request body
+// against the configured webhook secret.
+func (app *App) validGitSignature(body []byte, signature string) bool {
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(app.GitWebhookSecret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// referencedKeys returns the distinct task keys mentioned in text. Branch
+// names are matched case-insensitively since they are usually lowercase.
+func referencedKeys(text string, ignoreCase bool) []string {
+	if ignoreCase {
+		text = strings.ToUpper(text)
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for _, match := range taskRefPattern.FindAllString(text, -1) {
+		if !seen[match] {
+			seen[match] = true
+			keys = append(keys, match)
+		}
+	}
+	return keys
+}
+
+func (app *App) taskIDsByKey(keys []string) (map[string]int, error) {
+	ids := make(map[string]int)
+	if len(keys) == 0 {
+		return ids, nil
+	}
+
+	rows, err := app.DB.Query(`SELECT tasks.id, projects.key || '-' || tasks.number FROM tasks
+		JOIN columns ON columns.id = tasks.column_id
+		JOIN boards ON boards.id = columns.board_id
+		JOIN projects ON projects.id = boards.project_id
+		WHERE tasks.deleted_at IS NULL AND projects.key || '-' || tasks.number = ANY($1)`, pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			return nil, err
+		}
+		ids[key] = id
+	}
+	return ids, rows.Err()
+}
+
+type gitReference struct {
+	keys     []string
+	link     types.TaskLink
+	logEntry string
+}
+
+func gitReferences(event types.GitEvent) ([]gitReference, error) {
+	branchKeys := referencedKeys(event.Branch, true)
+	var references []gitReference
+
+	for _, commit := range event.Commits {
+		commit.SHA = strings.ToLower(strings.TrimSpace(commit.SHA))
+		if !commitSHAPattern.MatchString(commit.SHA) {
+			return nil, &apiError{http.StatusBadRequest, "Commit sha must be 7 to 40 hexadecimal characters"}
+		}
+		if !isValidLinkURL(commit.URL) {
+			return nil, &apiError{http.StatusBadRequest, "Commit url must be an absolute http or https URL"}
+		}
+
+		title, _, _ := strings.Cut(commit.Message, "\n")
+		references = append(references, gitReference{
+			keys: append(referencedKeys(commit.Message, false), branchKeys...),
+			link: types.TaskLink{
+				Type:  LinkCommit,
+				URL:   commit.URL,
+				Title: truncate(strings.TrimSpace(title), 255),
+				Metadata: map[string]any{
+					"sha":        commit.SHA,
+					"author":     commit.Author,
+					"repository": event.Repository,
+					"branch":     event.Branch,
+				},
+			},
+			logEntry: fmt.Sprintf("Commit %s references this task", commit.SHA[:7]),
+		})
+	}
+
+	if pr := event.PullRequest; pr != nil {
+		if !isValidLinkURL(pr.URL) {
+			return nil, &apiError{http.StatusBadRequest, "Pull request url must be an absolute http or https URL"}
+		}
+		references = append(references, gitReference{
+			keys: append(referencedKeys(pr.Title, false), branchKeys...),
+			link: types.TaskLink{
+				Type:  LinkPullRequest,
+				URL:   pr.URL,
+				Title: truncate(strings.TrimSpace(pr.Title), 255),
+				Metadata: map[string]any{
+					"number":     pr.Number,
+					"state":      pr.State,
+					"repository": event.Repository,
+					"branch":     event.Branch,
+				},
+			},
+			logEntry: fmt.Sprintf("Pull request #%d references this task", pr.Number),
+		})
+	}
+	return references, nil
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length])
+}
+
+// GitEventHandler receives pushes and pull request updates from a git host
+// and links every commit and pull request to the tasks whose keys appear in
+// its message, title or branch name.
+func (app *App) GitEventHandler(w http.ResponseWriter, r *http.Request) {
+	if app.GitWebhookSecret == "" {
+		http.Error(w, "Git webhook is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxGitEventSize+1))
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxGitEventSize {
+		http.Error(w, "Request payload is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !app.validGitSignature(body, r.Header.Get("X-Signature-256")) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var event types.GitEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	references, err := gitReferences(event)
+	if err != nil {
+		respondError(w, err, "Invalid git event")
+		return
+	}
+
+	var keys []string
+	for _, reference := range references {
+		keys = append(keys, reference.keys...)
+	}
+	taskIDs, err := app.taskIDsByKey(keys)
+	if err != nil {
+		http.Error(w, "Database error while resolving task keys", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result := types.GitEventResult{Linked: []types.TaskLink{}}
+	for _, reference := range references {
+		linked := make(map[int]bool)
+		for _, key := range reference.keys {
+			taskID, ok := taskIDs[key]
+			if !ok || linked[taskID] {
+				continue
+			}
+			linked[taskID] = true
+
+			link := reference.link
+			link.TaskID = taskID
+			metadata, _ := json.Marshal(link.Metadata)
+
+			var inserted bool
+			err := tx.QueryRow(`INSERT INTO task_links (task_id, type, url, title, metadata)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (task_id, type, url) DO UPDATE SET title = EXCLUDED.title, metadata = EXCLUDED.metadata
+				RETURNING id, created_at, xmax = 0`,
+				taskID, link.Type, link.URL, link.Title, metadata).Scan(&link.ID, &link.CreatedAt, &inserted)
+			if err != nil {
+				http.Error(w, "Error linking git reference", http.StatusInternalServerError)
+				return
+			}
+			link.CreatedAt = link.CreatedAt.UTC()
+
+			if inserted {
+				err = logTaskAction(tx, taskID, 0, "git_reference", reference.logEntry,
+					recordChange(nil, "links", nil, link.URL)...)
+				if err != nil {
+					http.Error(w, "Error logging git reference", http.StatusInternalServerError)
+					return
+				}
+			}
+			result.Linked = append(result.Linked, link)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error linking git references", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,320 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"kanban-board/types"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	nethtml "golang.org/x/net/html"
+)
+
+const descriptionCacheBatchSize = 500
+
+var (
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	mentionPattern = regexp.MustCompile(`(^|[^\w@])@([A-Za-z0-9_][A-Za-z0-9_.-]*[A-Za-z0-9_]|[A-Za-z0-9_])`)
+	taskRefPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9]{1,9})-([0-9]+)\b`)
+
+	descriptionPolicy = newDescriptionPolicy()
+)
+
+func newDescriptionPolicy() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^(task-ref|mention)$`)).OnElements("a")
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
+	return policy
+}
+
+func descriptionHash(description string) string {
+	sum := sha256.Sum256([]byte(description))
+	return hex.EncodeToString(sum[:])
+}
+
+// renderDescription turns a CommonMark/GFM description into sanitized HTML.
+// The result only depends on the description, so it is cached with the row;
+// references are linked when it is read.
+func renderDescription(description string) (string, error) {
+	if strings.TrimSpace(description) == "" {
+		return "", nil
+	}
+
+	var rendered bytes.Buffer
+	if err := markdown.Convert([]byte(description), &rendered); err != nil {
+		return "", err
+	}
+	return descriptionPolicy.Sanitize(rendered.String()), nil
+}
+
+// storeDescriptionHTML caches the rendered description of a row of table.
+// It is called wherever a description is written.
+func storeDescriptionHTML(db querier, table string, id int, description string) error {
+	descriptionHTML, err := renderDescription(description)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("UPDATE "+table+" SET description_html = $1, description_html_hash = $2 WHERE id = $3",
+		descriptionHTML, descriptionHash(description), id)
+	return err
+}
+
+// FillDescriptionCache renders the descriptions that have no cached HTML yet,
+// such as rows written before the cache existed.
+func (app *App) FillDescriptionCache() error {
+	for _, table := range []string{"tasks", "projects"} {
+		for {
+			rows, err := app.DB.Query("SELECT id, description FROM "+table+
+				" WHERE description_html_hash IS NULL ORDER BY id LIMIT $1", descriptionCacheBatchSize)
+			if err != nil {
+				return err
+			}
+			descriptions := make(map[int]string)
+			for rows.Next() {
+				var id int
+				var description string
+				if err := rows.Scan(&id, &description); err != nil {
+					rows.Close()
+					return err
+				}
+				descriptions[id] = description
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			if len(descriptions) == 0 {
+				break
+			}
+
+			for id, description := range descriptions {
+				if err := storeDescriptionHTML(app.DB, table, id, description); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// linkReferences rewrites the text of sanitized HTML, leaving code and
+// existing links alone, so task keys of existing projects and @mentions of
+// existing users become links. linkText escapes the text it links, so the
+// result needs no further sanitizing.
+func linkReferences(rendered string, projectKeys map[string]bool, userIDs map[string]int) string {
+	if len(projectKeys) == 0 && len(userIDs) == 0 {
+		return rendered
+	}
+
+	var out strings.Builder
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(rendered))
+	skipDepth := 0
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == nethtml.ErrorToken {
+			break
+		}
+		raw := string(tokenizer.Raw())
+
+		switch tokenType {
+		case nethtml.StartTagToken, nethtml.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "a", "code", "pre":
+				if tokenType == nethtml.StartTagToken {
+					skipDepth++
+				} else if skipDepth > 0 {
+					skipDepth--
+				}
+			}
+			out.WriteString(raw)
+		case nethtml.TextToken:
+			if skipDepth > 0 {
+				out.WriteString(raw)
+				continue
+			}
+			out.WriteString(linkText(html.UnescapeString(raw), projectKeys, userIDs))
+		default:
+			out.WriteString(raw)
+		}
+	}
+	return out.String()
+}
+
+func linkText(text string, projectKeys map[string]bool, userIDs map[string]int) string {
+	escaped := html.EscapeString(text)
+
+	escaped = taskRefPattern.ReplaceAllStringFunc(escaped, func(ref string) string {
+		match := taskRefPattern.FindStringSubmatch(ref)
+		if !projectKeys[match[1]] {
+			return ref
+		}
+		return `<a href="/tasks/` + ref + `" class="task-ref">` + ref + `</a>`
+	})
+
+	return mentionPattern.ReplaceAllStringFunc(escaped, func(mention string) string {
+		match := mentionPattern.FindStringSubmatch(mention)
+		userID, ok := userIDs[match[2]]
+		if !ok {
+			return mention
+		}
+		return match[1] + `<a href="/users/` + strconv.Itoa(userID) + `" class="mention">@` + match[2] + `</a>`
+	})
+}
+
+func (app *App) lookupReferences(text string) (map[string]bool, map[string]int, error) {
+	var keys, usernames []string
+	for _, match := range taskRefPattern.FindAllStringSubmatch(text, -1) {
+		keys = append(keys, match[1])
+	}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		usernames = append(usernames, match[2])
+	}
+
+	projectKeys := make(map[string]bool)
+	if len(keys) > 0 {
+		rows, err := app.DB.Query("SELECT key FROM projects WHERE key = ANY($1) AND deleted_at IS NULL", pq.Array(keys))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				return nil, nil, err
+			}
+			projectKeys[key] = true
+		}
+		if err := rows.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	userIDs := make(map[string]int)
+	if len(usernames) > 0 {
+		rows, err := app.DB.Query("SELECT id, username FROM users WHERE username = ANY($1)", pq.Array(usernames))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id int
+			var username string
+			if err := rows.Scan(&id, &username); err != nil {
+				return nil, nil, err
+			}
+			userIDs[username] = id
+		}
+		if err := rows.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return projectKeys, userIDs, nil
+}
+
+// cachedDescriptions returns the HTML of each description from the cache
+// stored with the row while its source hash still matches. References are
+// resolved on every read, so links follow projects and users being added or
+// removed. Read paths never write the cache.
+func (app *App) cachedDescriptions(table string, descriptions map[int]string) (map[int]string, error) {
+	rendered := make(map[int]string, len(descriptions))
+	if len(descriptions) == 0 {
+		return rendered, nil
+	}
+
+	ids := make([]int64, 0, len(descriptions))
+	for id := range descriptions {
+		ids = append(ids, int64(id))
+	}
+
+	rows, err := app.DB.Query("SELECT id, COALESCE(description_html, ''), COALESCE(description_html_hash, '') FROM "+table+
+		" WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stale := make(map[int]bool, len(descriptions))
+	for id := range descriptions {
+		stale[id] = true
+	}
+	for rows.Next() {
+		var id int
+		var cachedHTML, cachedHash string
+		if err := rows.Scan(&id, &cachedHTML, &cachedHash); err != nil {
+			return nil, err
+		}
+		if cachedHash != "" && cachedHash == descriptionHash(descriptions[id]) {
+			rendered[id] = cachedHTML
+			delete(stale, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Rows the cache was not filled for yet, or whose description was changed
+	// by a transaction that has not committed, are rendered without being stored.
+	for id := range stale {
+		descriptionHTML, err := renderDescription(descriptions[id])
+		if err != nil {
+			return nil, err
+		}
+		rendered[id] = descriptionHTML
+	}
+
+	all := make([]string, 0, len(rendered))
+	for _, descriptionHTML := range rendered {
+		all = append(all, descriptionHTML)
+	}
+	projectKeys, userIDs, err := app.lookupReferences(strings.Join(all, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	for id, descriptionHTML := range rendered {
+		rendered[id] = linkReferences(descriptionHTML, projectKeys, userIDs)
+	}
+	return rendered, nil
+}
+
+func (app *App) attachDescriptionHTML(tasks []types.Task) error {
+	descriptions := make(map[int]string, len(tasks))
+	for _, task := range tasks {
+		descriptions[task.ID] = task.Description
+	}
+
+	rendered, err := app.cachedDescriptions("tasks", descriptions)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		tasks[i].DescriptionHTML = rendered[tasks[i].ID]
+	}
+	return nil
+}
+
+func (app *App) attachProjectDescriptionHTML(projects []types.Project) error {
+	descriptions := make(map[int]string, len(projects))
+	for _, project := range projects {
+		descriptions[project.ID] = project.Description
+	}
+
+	rendered, err := app.cachedDescriptions("projects", descriptions)
+	if err != nil {
+		return err
+	}
+	for i := range projects {
+		projects[i].DescriptionHTML = rendered[projects[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (app *App) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	query := "SELECT id, user_id, task_id, action_type, message, read_at, created_at FROM notifications WHERE user_id = $1"
+	if r.URL.Query().Get("unread") == "true" {
+		query += " AND read_at IS NULL"
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT 100"
+
+	rows, err := app.DB.Query(query, userID)
+	if err != nil {
+		http.Error(w, "Database error while fetching notifications", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var notifications []types.Notification
+	for rows.Next() {
+		var notification types.Notification
+		if err := rows.Scan(&notification.ID, &notification.UserID, &notification.TaskID, &notification.ActionType,
+			&notification.Message, &notification.ReadAt, &notification.CreatedAt); err != nil {
+			http.Error(w, "Error scanning notifications", http.StatusInternalServerError)
+			return
+		}
+		notifications = append(notifications, notification)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
+
+func (app *App) MarkNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	notificationID := chi.URLParam(r, "id")
+	if notificationID == "" {
+		http.Error(w, "Notification ID is required", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	result, err := app.DB.Exec("UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND read_at IS NULL",
+		notificationID, userID)
+	if err != nil {
+		http.Error(w, "Database error while updating notification", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Notification marked as read"))
+}
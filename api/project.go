@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"kanban-board/types"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
 )
 
 func (app *App) CreateProjectHandler(w http.ResponseWriter, r *http.Request) {
@@ -35,7 +38,7 @@ func (app *App) CreateProjectHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var existingProjectName string
-	err = app.DB.QueryRow("SELECT name FROM projects WHERE name = $1", project.Name).Scan(&existingProjectName)
+	err = app.DB.QueryRow("SELECT name FROM projects WHERE name = $1 AND deleted_at IS NULL", project.Name).Scan(&existingProjectName)
 	if err != nil && err != sql.ErrNoRows {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -45,12 +48,34 @@ func (app *App) CreateProjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.DB.QueryRow("INSERT INTO projects (name, user_id, description) VALUES ($1, $2, $3) RETURNING id, created_at",
-		project.Name, project.UserID, project.Description).Scan(&project.ID, &project.CreatedAt)
+	if project.Key == "" {
+		project.Key, err = app.uniqueProjectKey(project.Name)
+		if err != nil {
+			http.Error(w, "Database error while generating project key", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		project.Key = strings.ToUpper(project.Key)
+		if !projectKeyPattern.MatchString(project.Key) {
+			http.Error(w, "Project key must be 2-10 letters or digits starting with a letter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = app.DB.QueryRow("INSERT INTO projects (name, user_id, description, key) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		project.Name, project.UserID, project.Description, project.Key).Scan(&project.ID, &project.CreatedAt)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "This project key is already in use", http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error creating project", http.StatusInternalServerError)
 		return
 	}
+	if err := storeDescriptionHTML(app.DB, "projects", project.ID, project.Description); err != nil {
+		http.Error(w, "Error rendering project description", http.StatusInternalServerError)
+		return
+	}
 
 	_, err = app.DB.Exec("INSERT INTO project_users (project_id, user_id, role) VALUES ($1, $2, 'user')",
 		project.ID, project.UserID)
@@ -61,6 +86,7 @@ func (app *App) CreateProjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	response := types.Project{
 		ID:          project.ID,
+		Key:         project.Key,
 		Name:        project.Name,
 		Description: project.Description,
 		UserID:      project.UserID,
@@ -68,16 +94,22 @@ func (app *App) CreateProjectHandler(w http.ResponseWriter, r *http.Request) {
 		Boards:      nil,
 	}
 
+	projects := []types.Project{response}
+	if err := app.attachProjectDescriptionHTML(projects); err != nil {
+		http.Error(w, "Error rendering project description", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(projects[0])
 }
 
 func (app *App) GetProjectByID(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "id")
 	var project types.Project
 
-	err := app.DB.QueryRow("SELECT id, name, description, user_id, created_at FROM projects WHERE id = $1", projectID).
-		Scan(&project.ID, &project.Name, &project.Description, &project.UserID, &project.CreatedAt)
+	err := app.DB.QueryRow("SELECT id, key, name, description, user_id, created_at FROM projects WHERE id = $1 AND deleted_at IS NULL", projectID).
+		Scan(&project.ID, &project.Key, &project.Name, &project.Description, &project.UserID, &project.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Project not found", http.StatusNotFound)
@@ -87,12 +119,18 @@ func (app *App) GetProjectByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	projects := []types.Project{project}
+	if err := app.attachProjectDescriptionHTML(projects); err != nil {
+		http.Error(w, "Error rendering project description", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(project)
+	json.NewEncoder(w).Encode(projects[0])
 }
 
 func (app *App) GetProjects(w http.ResponseWriter, r *http.Request) {
-	rows, err := app.DB.Query("SELECT id, name, description, user_id, created_at FROM projects")
+	rows, err := app.DB.Query("SELECT id, key, name, description, user_id, created_at FROM projects WHERE deleted_at IS NULL")
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -102,12 +140,16 @@ func (app *App) GetProjects(w http.ResponseWriter, r *http.Request) {
 	var projects []types.Project
 	for rows.Next() {
 		var project types.Project
-		if err := rows.Scan(&project.ID, &project.Name, &project.Description, &project.UserID, &project.CreatedAt); err != nil {
+		if err := rows.Scan(&project.ID, &project.Key, &project.Name, &project.Description, &project.UserID, &project.CreatedAt); err != nil {
 			http.Error(w, "Error scanning projects", http.StatusInternalServerError)
 			return
 		}
 		projects = append(projects, project)
 	}
+	if err := app.attachProjectDescriptionHTML(projects); err != nil {
+		http.Error(w, "Error rendering project descriptions", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(projects)
@@ -120,20 +162,19 @@ func (app *App) DeleteProjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := app.DB.Exec("DELETE FROM projects WHERE id = $1", projectID)
+	id, err := strconv.Atoi(projectID)
 	if err != nil {
-		http.Error(w, "Database error while deleting project", http.StatusInternalServerError)
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	err = app.moveToTrash(r, "project", id)
 	if err != nil {
-		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
-		return
-	}
-
-	if rowsAffected == 0 {
-		http.Error(w, "Project not found", http.StatusNotFound)
+		if apiErr, ok := err.(*apiError); ok && apiErr.Status == http.StatusNotFound {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while deleting project", http.StatusInternalServerError)
 		return
 	}
 
@@ -156,8 +197,8 @@ func (app *App) UpdateProjectHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var existingProject types.Project
-	err = app.DB.QueryRow("SELECT id, name, description, user_id, created_at FROM projects WHERE id = $1",
-		projectID).Scan(&existingProject.ID, &existingProject.Name, &existingProject.Description, &existingProject.UserID, &existingProject.CreatedAt)
+	err = app.DB.QueryRow("SELECT id, key, name, description, user_id, created_at FROM projects WHERE id = $1 AND deleted_at IS NULL",
+		projectID).Scan(&existingProject.ID, &existingProject.Key, &existingProject.Name, &existingProject.Description, &existingProject.UserID, &existingProject.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Project not found", http.StatusNotFound)
@@ -176,15 +217,43 @@ func (app *App) UpdateProjectHandler(w http.ResponseWriter, r *http.Request) {
 		existingProject.Name = updateData.Name
 	}
 
+	if updateData.Key != "" {
+		key := strings.ToUpper(updateData.Key)
+		if !projectKeyPattern.MatchString(key) {
+			http.Error(w, "Project key must be 2-10 letters or digits starting with a letter", http.StatusBadRequest)
+			return
+		}
+		_, err = app.DB.Exec("UPDATE projects SET key = $1 WHERE id = $2", key, projectID)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				http.Error(w, "This project key is already in use", http.StatusConflict)
+				return
+			}
+			http.Error(w, "Error updating project key", http.StatusInternalServerError)
+			return
+		}
+		existingProject.Key = key
+	}
+
 	if updateData.Description != "" {
 		_, err = app.DB.Exec("UPDATE projects SET description = $1 WHERE id = $2", updateData.Description, projectID)
 		if err != nil {
 			http.Error(w, "Error updating project description", http.StatusInternalServerError)
 			return
 		}
+		if err := storeDescriptionHTML(app.DB, "projects", existingProject.ID, updateData.Description); err != nil {
+			http.Error(w, "Error rendering project description", http.StatusInternalServerError)
+			return
+		}
 		existingProject.Description = updateData.Description
 	}
 
+	projects := []types.Project{existingProject}
+	if err := app.attachProjectDescriptionHTML(projects); err != nil {
+		http.Error(w, "Error rendering project description", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(existingProject)
+	json.NewEncoder(w).Encode(projects[0])
 }
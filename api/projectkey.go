@@ -0,0 +1,104 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+	taskKeyPattern    = regexp.MustCompile(`^([A-Z][A-Z0-9]{1,9})-([0-9]+)$`)
+)
+
+// taskKeyExpr computes the "WEB-142" style key of a task from its project.
+const taskKeyExpr = `COALESCE((SELECT projects.key || '-' || tasks.number FROM columns
+	JOIN boards ON boards.id = columns.board_id
+	JOIN projects ON projects.id = boards.project_id
+	WHERE columns.id = tasks.column_id), '')`
+
+// deriveProjectKey builds a key from the initials of a multi-word name,
+// or the first letters of a single word, e.g. "Web Platform" -> "WP", "Website" -> "WEB".
+func deriveProjectKey(name string) string {
+	words := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	for len(words) > 0 && !unicode.IsLetter(rune(words[0][0])) {
+		words = words[1:]
+	}
+
+	var key string
+	switch {
+	case len(words) == 0:
+		key = "PRJ"
+	case len(words) == 1:
+		key = words[0][:min(3, len(words[0]))]
+	default:
+		for _, word := range words[:min(4, len(words))] {
+			key += word[:1]
+		}
+	}
+	if len(key) < 2 {
+		key += "X"
+	}
+	return key
+}
+
+// uniqueProjectKey returns the derived key for the name, suffixed with a
+// number if another project already uses it.
+func (app *App) uniqueProjectKey(name string) (string, error) {
+	base := deriveProjectKey(name)
+	key := base
+	for i := 2; ; i++ {
+		var exists bool
+		err := app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE key = $1)", key).Scan(&exists)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return key, nil
+		}
+		suffix := strconv.Itoa(i)
+		key = base[:min(len(base), 10-len(suffix))] + suffix
+	}
+}
+
+// nextTaskNumber allocates the next task number of the project. The row lock
+// taken by the UPDATE serializes concurrent allocations.
+func nextTaskNumber(db querier, projectID int) (int, string, error) {
+	var number int
+	var projectKey string
+	err := db.QueryRow("UPDATE projects SET task_counter = task_counter + 1 WHERE id = $1 RETURNING task_counter, key",
+		projectID).Scan(&number, &projectKey)
+	if err != nil {
+		return 0, "", err
+	}
+	return number, fmt.Sprintf("%s-%d", projectKey, number), nil
+}
+
+// resolveTaskID accepts either a numeric task ID or a task key like WEB-142.
+func (app *App) resolveTaskID(ref string) (int, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return id, nil
+	}
+
+	match := taskKeyPattern.FindStringSubmatch(strings.ToUpper(ref))
+	if match == nil {
+		return 0, &apiError{http.StatusBadRequest, "Invalid task ID or key"}
+	}
+
+	var id int
+	err := app.DB.QueryRow(`SELECT tasks.id FROM tasks
+		JOIN columns ON columns.id = tasks.column_id
+		JOIN boards ON boards.id = columns.board_id
+		JOIN projects ON projects.id = boards.project_id
+		WHERE projects.key = $1 AND tasks.number = $2`, match[1], match[2]).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, &apiError{http.StatusNotFound, "Task not found"}
+	}
+	return id, err
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const maxEmojiLength = 64
+
+var emojiShortcodePattern = regexp.MustCompile(`^:[a-z0-9_+-]+:$`)
+
+// validEmoji accepts a :shortcode: or a single unicode emoji: one grapheme
+// made of a keycap, a flag, or symbols joined by zero width joiners, each
+// optionally followed by a skin tone, variation selector or tag characters.
+func validEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > maxEmojiLength {
+		return false
+	}
+	if emojiShortcodePattern.MatchString(emoji) {
+		return true
+	}
+
+	runes := []rune(emoji)
+	if first := runes[0]; first == '#' || first == '*' || (first >= '0' && first <= '9') {
+		rest := string(runes[1:])
+		return rest == "\u20e3" || rest == "\ufe0f\u20e3"
+	}
+	if isRegionalIndicator(runes[0]) {
+		return len(runes) == 2 && isRegionalIndicator(runes[1])
+	}
+
+	expectSymbol := true
+	for _, r := range runes {
+		switch {
+		case expectSymbol:
+			if !unicode.Is(unicode.So, r) || isRegionalIndicator(r) {
+				return false
+			}
+			expectSymbol = false
+		case r == '\u200d':
+			expectSymbol = true
+		case r == '\ufe0e' || r == '\ufe0f' || (r >= 0x1f3fb && r <= 0x1f3ff) || (r >= 0xe0020 && r <= 0xe007f):
+		default:
+			// Anything else starts a second grapheme.
+			return false
+		}
+	}
+	return !expectSymbol
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// reactionTarget resolves the task of a reaction or vote request and checks
+// that the requesting user is a member of its project.
+func (app *App) reactionTarget(r *http.Request) (int, int, error) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, &apiError{http.StatusBadRequest, "Invalid task ID"}
+	}
+
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	projectID, err := app.taskProjectID(taskID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := app.checkProjectMember(projectID, userID); err != nil {
+		return 0, 0, err
+	}
+	return taskID, userID, nil
+}
+
+func (app *App) AddReactionHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, userID, err := app.reactionTarget(r)
+	if err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+
+	var reaction types.Reaction
+	if err := json.NewDecoder(r.Body).Decode(&reaction); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	reaction.Emoji = strings.TrimSpace(reaction.Emoji)
+	if !validEmoji(reaction.Emoji) {
+		http.Error(w, "Emoji must be a single emoji or a :shortcode:", http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.DB.Exec(`INSERT INTO task_reactions (task_id, user_id, emoji) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`, taskID, userID, reaction.Emoji)
+	if err != nil {
+		http.Error(w, "Error adding reaction", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Reaction already exists", http.StatusConflict)
+		return
+	}
+
+	app.writeTaskReactions(w, taskID)
+}
+
+func (app *App) RemoveReactionHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, userID, err := app.reactionTarget(r)
+	if err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+
+	emoji, err := url.PathUnescape(chi.URLParam(r, "emoji"))
+	if err != nil {
+		http.Error(w, "Invalid emoji", http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.DB.Exec("DELETE FROM task_reactions WHERE task_id = $1 AND user_id = $2 AND emoji = $3",
+		taskID, userID, emoji)
+	if err != nil {
+		http.Error(w, "Error removing reaction", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Reaction not found", http.StatusNotFound)
+		return
+	}
+
+	app.writeTaskReactions(w, taskID)
+}
+
+func (app *App) VoteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, userID, err := app.reactionTarget(r)
+	if err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+
+	// Votes prioritize work still to do; a finished task keeps its votes but takes no new ones.
+	var category string
+	err = app.DB.QueryRow("SELECT columns.category FROM tasks JOIN columns ON columns.id = tasks.column_id WHERE tasks.id = $1",
+		taskID).Scan(&category)
+	if err != nil {
+		http.Error(w, "Database error while checking column", http.StatusInternalServerError)
+		return
+	}
+	if category == CategoryDone {
+		http.Error(w, "Done tasks cannot be voted", http.StatusConflict)
+		return
+	}
+
+	result, err := app.DB.Exec("INSERT INTO task_votes (task_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		taskID, userID)
+	if err != nil {
+		http.Error(w, "Error voting for task", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Task is already voted", http.StatusConflict)
+		return
+	}
+
+	app.writeTaskReactions(w, taskID)
+}
+
+func (app *App) UnvoteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, userID, err := app.reactionTarget(r)
+	if err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+
+	result, err := app.DB.Exec("DELETE FROM task_votes WHERE task_id = $1 AND user_id = $2", taskID, userID)
+	if err != nil {
+		http.Error(w, "Error removing vote", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Task is not voted", http.StatusNotFound)
+		return
+	}
+
+	app.writeTaskReactions(w, taskID)
+}
+
+// writeTaskReactions responds with the aggregated reactions and votes of a task.
+func (app *App) writeTaskReactions(w http.ResponseWriter, taskID int) {
+	tasks := []types.Task{{ID: taskID}}
+	if err := app.attachReactions(tasks); err != nil {
+		http.Error(w, "Database error while fetching reactions", http.StatusInternalServerError)
+		return
+	}
+	if tasks[0].Reactions == nil {
+		tasks[0].Reactions = []types.ReactionCount{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"task_id":   taskID,
+		"reactions": tasks[0].Reactions,
+		"votes":     tasks[0].Votes,
+	})
+}
+
+func (app *App) attachReactions(tasks []types.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(tasks))
+	index := make(map[int]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = int64(task.ID)
+		index[task.ID] = i
+	}
+
+	rows, err := app.DB.Query(`SELECT task_id, emoji, COUNT(*) FROM task_reactions
+		WHERE task_id = ANY($1)
+		GROUP BY task_id, emoji
+		ORDER BY task_id, COUNT(*) DESC, MIN(created_at)`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int
+		var reaction types.ReactionCount
+		if err := rows.Scan(&taskID, &reaction.Emoji, &reaction.Count); err != nil {
+			return err
+		}
+		task := &tasks[index[taskID]]
+		task.Reactions = append(task.Reactions, reaction)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	voteRows, err := app.DB.Query("SELECT task_id, COUNT(*) FROM task_votes WHERE task_id = ANY($1) GROUP BY task_id",
+		pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer voteRows.Close()
+
+	for voteRows.Next() {
+		var taskID, votes int
+		if err := voteRows.Scan(&taskID, &votes); err != nil {
+			return err
+		}
+		tasks[index[taskID]].Votes = votes
+	}
+	return voteRows.Err()
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recurrenceRule is the subset of RFC 5545 RRULE we support:
+// FREQ=DAILY|WEEKLY|MONTHLY with INTERVAL, BYDAY, UNTIL and COUNT.
+type recurrenceRule struct {
+	freq     string
+	interval int
+	byDay    []weekdayNum
+	until    *time.Time
+	count    int
+}
+
+type weekdayNum struct {
+	weekday time.Weekday
+	ordinal int
+}
+
+const maxRecurrenceSearchDays = 3660
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+func parseRRule(value string) (recurrenceRule, error) {
+	rule := recurrenceRule{interval: 1}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return rule, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+			if rule.freq != "DAILY" && rule.freq != "WEEKLY" && rule.freq != "MONTHLY" {
+				return rule, fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return rule, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rule.interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return rule, fmt.Errorf("invalid COUNT %q", val)
+			}
+			rule.count = count
+		case "UNTIL":
+			until, err := parseRRuleTime(val)
+			if err != nil {
+				return rule, fmt.Errorf("invalid UNTIL %q", val)
+			}
+			rule.until = &until
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, err := parseWeekdayNum(code)
+				if err != nil {
+					return rule, err
+				}
+				rule.byDay = append(rule.byDay, day)
+			}
+		default:
+			return rule, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if rule.freq == "" {
+		return rule, fmt.Errorf("FREQ is required")
+	}
+	if rule.count > 0 && rule.until != nil {
+		return rule, fmt.Errorf("COUNT and UNTIL cannot be combined")
+	}
+	for _, day := range rule.byDay {
+		if day.ordinal != 0 && rule.freq != "MONTHLY" {
+			return rule, fmt.Errorf("BYDAY ordinals are only supported with FREQ=MONTHLY")
+		}
+	}
+
+	return rule, nil
+}
+
+func parseRRuleTime(value string) (time.Time, error) {
+	if len(value) == len("20060102") {
+		day, err := time.Parse("20060102", value)
+		return day.AddDate(0, 0, 1).Add(-time.Second), err
+	}
+	return time.Parse("20060102T150405Z", value)
+}
+
+func parseWeekdayNum(code string) (weekdayNum, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) < 2 {
+		return weekdayNum{}, fmt.Errorf("invalid BYDAY %q", code)
+	}
+
+	weekday, ok := weekdayCodes[code[len(code)-2:]]
+	if !ok {
+		return weekdayNum{}, fmt.Errorf("invalid BYDAY %q", code)
+	}
+
+	day := weekdayNum{weekday: weekday}
+	if prefix := code[:len(code)-2]; prefix != "" {
+		ordinal, err := strconv.Atoi(prefix)
+		if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+			return weekdayNum{}, fmt.Errorf("invalid BYDAY %q", code)
+		}
+		day.ordinal = ordinal
+	}
+	return day, nil
+}
+
+// next returns the first occurrence of the series starting at start that is
+// strictly after "after". The second result is false once the series is over.
+func (rule recurrenceRule) next(start, after time.Time, generated int) (time.Time, bool) {
+	if rule.count > 0 && generated >= rule.count {
+		return time.Time{}, false
+	}
+
+	day := truncateDay(start)
+	if after.After(start) {
+		day = truncateDay(after)
+	}
+
+	for i := 0; i <= maxRecurrenceSearchDays; i++ {
+		candidate := time.Date(day.Year(), day.Month(), day.Day(),
+			start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		if rule.until != nil && candidate.After(*rule.until) {
+			return time.Time{}, false
+		}
+		if !candidate.Before(start) && candidate.After(after) && rule.matches(start, candidate) {
+			return candidate, true
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}, false
+}
+
+func (rule recurrenceRule) matches(start, day time.Time) bool {
+	switch rule.freq {
+	case "DAILY":
+		days := int(truncateDay(day).Sub(truncateDay(start)).Hours() / 24)
+		return days%rule.interval == 0 && (len(rule.byDay) == 0 || rule.hasWeekday(day.Weekday()))
+	case "WEEKLY":
+		weeks := int(weekStart(day).Sub(weekStart(start)).Hours() / (24 * 7))
+		if weeks%rule.interval != 0 {
+			return false
+		}
+		if len(rule.byDay) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+		return rule.hasWeekday(day.Weekday())
+	case "MONTHLY":
+		months := (day.Year()-start.Year())*12 + int(day.Month()-start.Month())
+		if months%rule.interval != 0 {
+			return false
+		}
+		if len(rule.byDay) == 0 {
+			return day.Day() == start.Day()
+		}
+		for _, byDay := range rule.byDay {
+			if byDay.weekday != day.Weekday() {
+				continue
+			}
+			if byDay.ordinal == 0 || byDay.ordinal == monthWeekdayOrdinal(day, byDay.ordinal < 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (rule recurrenceRule) hasWeekday(weekday time.Weekday) bool {
+	for _, day := range rule.byDay {
+		if day.weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return truncateDay(t).AddDate(0, 0, -offset)
+}
+
+// monthWeekdayOrdinal returns which occurrence of its weekday the day is
+// within its month, counted from the end (as a negative number) if fromEnd is set.
+func monthWeekdayOrdinal(day time.Time, fromEnd bool) int {
+	if fromEnd {
+		lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		return -((lastDay-day.Day())/7 + 1)
+	}
+	return (day.Day()-1)/7 + 1
+}
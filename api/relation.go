@@ -0,0 +1,267 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const (
+	RelationBlocks     = "blocks"
+	RelationRelatesTo  = "relates_to"
+	RelationDuplicates = "duplicates"
+)
+
+func isValidRelationType(relationType string) bool {
+	switch relationType {
+	case RelationBlocks, RelationRelatesTo, RelationDuplicates:
+		return true
+	}
+	return false
+}
+
+func (app *App) CreateTaskRelationHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	if taskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var relation types.TaskRelation
+	err := json.NewDecoder(r.Body).Decode(&relation)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if relation.RelatedTaskID == 0 {
+		http.Error(w, "Related task ID is required", http.StatusBadRequest)
+		return
+	}
+	if !isValidRelationType(relation.Type) {
+		http.Error(w, "Relation type must be one of: blocks, relates_to, duplicates", http.StatusBadRequest)
+		return
+	}
+
+	err = app.DB.QueryRow("SELECT id FROM tasks WHERE id = $1 AND deleted_at IS NULL", taskID).Scan(&relation.TaskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while checking task", http.StatusInternalServerError)
+		return
+	}
+
+	if relation.TaskID == relation.RelatedTaskID {
+		http.Error(w, "A task cannot be related to itself", http.StatusBadRequest)
+		return
+	}
+
+	var relatedExists bool
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1 AND deleted_at IS NULL)", relation.RelatedTaskID).Scan(&relatedExists)
+	if err != nil {
+		http.Error(w, "Database error while checking related task", http.StatusInternalServerError)
+		return
+	}
+	if !relatedExists {
+		http.Error(w, "Related task does not exist", http.StatusNotFound)
+		return
+	}
+
+	var relationExists bool
+	err = app.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM task_relations
+		WHERE type = $3 AND ((task_id = $1 AND related_task_id = $2) OR (task_id = $2 AND related_task_id = $1)))`,
+		relation.TaskID, relation.RelatedTaskID, relation.Type).Scan(&relationExists)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if relationExists {
+		http.Error(w, "This relation already exists", http.StatusConflict)
+		return
+	}
+
+	if relation.Type == RelationBlocks {
+		createsCycle, err := app.blocksPathExists(relation.RelatedTaskID, relation.TaskID)
+		if err != nil {
+			http.Error(w, "Database error while checking dependency cycle", http.StatusInternalServerError)
+			return
+		}
+		if createsCycle {
+			http.Error(w, "This relation would create a dependency cycle", http.StatusConflict)
+			return
+		}
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`INSERT INTO task_relations (task_id, related_task_id, type)
+		VALUES ($1, $2, $3) RETURNING id, created_at`,
+		relation.TaskID, relation.RelatedTaskID, relation.Type).Scan(&relation.ID, &relation.CreatedAt)
+	if err != nil {
+		http.Error(w, "Error creating task relation", http.StatusInternalServerError)
+		return
+	}
+
+	changes := recordChange(nil, "relation", nil, relation)
+	err = logTaskAction(tx, relation.TaskID, app.optionalUserID(r), "relation", "Task relation added", changes...)
+	if err != nil {
+		http.Error(w, "Error logging task relation", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating task relation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(relation)
+}
+
+func (app *App) GetTaskRelations(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	if taskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := app.DB.Query(`SELECT id, task_id, related_task_id, type, created_at FROM task_relations
+		WHERE task_id = $1 OR related_task_id = $1 ORDER BY id`, taskID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var relations []types.TaskRelation
+	for rows.Next() {
+		var relation types.TaskRelation
+		if err := rows.Scan(&relation.ID, &relation.TaskID, &relation.RelatedTaskID, &relation.Type, &relation.CreatedAt); err != nil {
+			http.Error(w, "Error scanning task relations", http.StatusInternalServerError)
+			return
+		}
+		relations = append(relations, relation)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(relations)
+}
+
+func (app *App) DeleteTaskRelationHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	relationID := chi.URLParam(r, "relationID")
+	if taskID == "" || relationID == "" {
+		http.Error(w, "Task ID and relation ID are required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var relation types.TaskRelation
+	err = tx.QueryRow(`DELETE FROM task_relations WHERE id = $1 AND (task_id = $2 OR related_task_id = $2)
+		RETURNING id, task_id, related_task_id, type, created_at`, relationID, taskID).
+		Scan(&relation.ID, &relation.TaskID, &relation.RelatedTaskID, &relation.Type, &relation.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task relation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while deleting task relation", http.StatusInternalServerError)
+		return
+	}
+
+	changes := recordChange(nil, "relation", relation, nil)
+	err = logTaskAction(tx, relation.TaskID, app.optionalUserID(r), "relation", "Task relation removed", changes...)
+	if err != nil {
+		http.Error(w, "Error logging task relation removal", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error while deleting task relation", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Task relation deleted successfully"))
+}
+
+// blocksPathExists reports whether "from" transitively blocks "to".
+// Adding the edge to -> from is rejected when it does, since it would close a cycle.
+func (app *App) blocksPathExists(from, to int) (bool, error) {
+	var exists bool
+	err := app.DB.QueryRow(`
+		WITH RECURSIVE reachable(id) AS (
+			SELECT related_task_id FROM task_relations WHERE task_id = $1 AND type = 'blocks'
+			UNION
+			SELECT task_relations.related_task_id FROM task_relations
+			JOIN reachable ON task_relations.task_id = reachable.id
+			WHERE task_relations.type = 'blocks'
+		)
+		SELECT EXISTS(SELECT 1 FROM reachable WHERE id = $2)`, from, to).Scan(&exists)
+	return exists, err
+}
+
+func (app *App) attachBlockers(tasks []types.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(tasks))
+	index := make(map[int]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = int64(task.ID)
+		index[task.ID] = i
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT task_relations.related_task_id, tasks.id, tasks.title, tasks.column_id, columns.category
+		FROM task_relations
+		JOIN tasks ON tasks.id = task_relations.task_id
+		JOIN columns ON columns.id = tasks.column_id
+		WHERE task_relations.type = 'blocks' AND task_relations.related_task_id = ANY($1)
+			AND tasks.deleted_at IS NULL
+		ORDER BY tasks.id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var blockedID int
+		var category string
+		var blocker types.Blocker
+		if err := rows.Scan(&blockedID, &blocker.TaskID, &blocker.Title, &blocker.ColumnID, &category); err != nil {
+			return err
+		}
+		blocker.Resolved = category == CategoryDone
+		i := index[blockedID]
+		tasks[i].BlockedBy = append(tasks[i].BlockedBy, blocker)
+	}
+	return rows.Err()
+}
+
+func hasUnresolvedBlockers(task types.Task) bool {
+	for _, blocker := range task.BlockedBy {
+		if !blocker.Resolved {
+			return true
+		}
+	}
+	return false
+}
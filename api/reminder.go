@@ -0,0 +1,316 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kanban-board/types"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	reminderBatchSize = 100
+	maxBeforeHours    = 24 * 365
+)
+
+// reminderFireExpr is the time a reminder is due: its fixed remind_at, or
+// before_hours ahead of the start (UTC) of the task's value for a date field,
+// so relative reminders follow changes to the due date.
+const reminderFireExpr = `COALESCE(task_reminders.remind_at, (
+	SELECT (task_custom_values.value #>> '{}')::date - make_interval(hours => task_reminders.before_hours)
+	FROM task_custom_values
+	WHERE task_custom_values.task_id = task_reminders.task_id AND task_custom_values.field_id = task_reminders.field_id))`
+
+const reminderColumns = `task_reminders.id, task_reminders.task_id, task_reminders.user_id, ` + reminderFireExpr + `,
+	COALESCE(custom_fields.name, ''), task_reminders.before_hours, task_reminders.note, task_reminders.fired_at, task_reminders.created_at`
+
+const reminderFrom = ` FROM task_reminders LEFT JOIN custom_fields ON custom_fields.id = task_reminders.field_id`
+
+func scanReminder(row scanner, reminder *types.Reminder) error {
+	err := row.Scan(&reminder.ID, &reminder.TaskID, &reminder.UserID, &reminder.RemindAt, &reminder.Field,
+		&reminder.BeforeHours, &reminder.Note, &reminder.FiredAt, &reminder.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for _, t := range []*time.Time{reminder.RemindAt, reminder.FiredAt} {
+		if t != nil {
+			*t = t.UTC()
+		}
+	}
+	reminder.CreatedAt = reminder.CreatedAt.UTC()
+	return nil
+}
+
+func (app *App) queryReminders(conditions string, args ...any) ([]types.Reminder, error) {
+	rows, err := app.DB.Query("SELECT "+reminderColumns+reminderFrom+" WHERE "+conditions+
+		" ORDER BY "+reminderFireExpr+" NULLS LAST, task_reminders.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []types.Reminder{}
+	for rows.Next() {
+		var reminder types.Reminder
+		if err := scanReminder(rows, &reminder); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+func (app *App) CreateReminderHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	var reminder types.Reminder
+	err = json.NewDecoder(r.Body).Decode(&reminder)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	projectID, err := app.taskProjectID(taskID)
+	if err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+	if err := app.checkProjectMember(projectID, userID); err != nil {
+		respondError(w, err, "Database error while checking project membership")
+		return
+	}
+
+	now := time.Now().UTC()
+	var fieldID *int
+	switch {
+	case reminder.RemindAt != nil && (reminder.Field != "" || reminder.BeforeHours != nil):
+		http.Error(w, "Use either remind_at or field with before_hours", http.StatusBadRequest)
+		return
+
+	case reminder.RemindAt != nil:
+		remindAt := reminder.RemindAt.UTC()
+		if !remindAt.After(now) {
+			http.Error(w, "remind_at must be in the future", http.StatusBadRequest)
+			return
+		}
+		reminder.RemindAt = &remindAt
+
+	case reminder.Field != "" && reminder.BeforeHours != nil:
+		if *reminder.BeforeHours < 0 || *reminder.BeforeHours > maxBeforeHours {
+			http.Error(w, "before_hours must be between 0 and 8760", http.StatusBadRequest)
+			return
+		}
+
+		var id int
+		var fieldType string
+		err = app.DB.QueryRow("SELECT id, type FROM custom_fields WHERE project_id = $1 AND name = $2",
+			projectID, reminder.Field).Scan(&id, &fieldType)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Custom field does not exist", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Database error while checking custom field", http.StatusInternalServerError)
+			return
+		}
+		if fieldType != FieldDate {
+			http.Error(w, "Reminders can only be relative to a date field", http.StatusBadRequest)
+			return
+		}
+		fieldID = &id
+
+	default:
+		http.Error(w, "Either remind_at or field with before_hours is required", http.StatusBadRequest)
+		return
+	}
+	reminder.Note = strings.TrimSpace(reminder.Note)
+
+	var reminderID int
+	err = app.DB.QueryRow(`INSERT INTO task_reminders (task_id, user_id, remind_at, field_id, before_hours, note)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		taskID, userID, reminder.RemindAt, fieldID, reminder.BeforeHours, reminder.Note).Scan(&reminderID)
+	if err != nil {
+		http.Error(w, "Error creating reminder", http.StatusInternalServerError)
+		return
+	}
+
+	reminders, err := app.queryReminders("task_reminders.id = $1", reminderID)
+	if err != nil || len(reminders) == 0 {
+		http.Error(w, "Database error while fetching reminder", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reminders[0])
+}
+
+func (app *App) GetTaskReminders(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	reminders, err := app.queryReminders("task_reminders.task_id = $1 AND task_reminders.user_id = $2", taskID, userID)
+	if err != nil {
+		http.Error(w, "Database error while fetching reminders", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reminders)
+}
+
+func (app *App) GetReminders(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	conditions := "task_reminders.user_id = $1"
+	if r.URL.Query().Get("include_fired") != "true" {
+		conditions += " AND task_reminders.fired_at IS NULL"
+	}
+
+	reminders, err := app.queryReminders(conditions, userID)
+	if err != nil {
+		http.Error(w, "Database error while fetching reminders", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reminders)
+}
+
+func (app *App) DeleteReminderHandler(w http.ResponseWriter, r *http.Request) {
+	reminderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid reminder ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	result, err := app.DB.Exec("DELETE FROM task_reminders WHERE id = $1 AND user_id = $2", reminderID, userID)
+	if err != nil {
+		http.Error(w, "Database error while deleting reminder", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Reminder not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Reminder deleted successfully"))
+}
+
+// StartReminderScheduler delivers due reminders as notifications every
+// interval. A reminder is marked fired in the same transaction that creates
+// its notification, so it fires exactly once across restarts and instances.
+func (app *App) StartReminderScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			for {
+				fired, err := app.fireDueReminders(time.Now().UTC())
+				if err != nil {
+					log.Printf("Error firing reminders: %v", err)
+				}
+				if err != nil || fired < reminderBatchSize {
+					break
+				}
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func (app *App) fireDueReminders(now time.Time) (int, error) {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT task_reminders.id, task_reminders.task_id, task_reminders.user_id,
+		task_reminders.note, tasks.title
+		FROM task_reminders
+		JOIN tasks ON tasks.id = task_reminders.task_id
+		WHERE task_reminders.fired_at IS NULL AND tasks.deleted_at IS NULL AND `+reminderFireExpr+` <= $1
+		ORDER BY task_reminders.id LIMIT $2 FOR UPDATE OF task_reminders SKIP LOCKED`, now, reminderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	type dueReminder struct {
+		id, taskID, userID int
+		note, title        string
+	}
+	var due []dueReminder
+	for rows.Next() {
+		var reminder dueReminder
+		if err := rows.Scan(&reminder.id, &reminder.taskID, &reminder.userID, &reminder.note, &reminder.title); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, reminder)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, reminder := range due {
+		message := "Reminder: " + reminder.title
+		if reminder.note != "" {
+			message += " - " + reminder.note
+		}
+		_, err := tx.Exec(`INSERT INTO notifications (user_id, task_id, action_type, message, created_at)
+			VALUES ($1, $2, 'reminder', $3, $4)`, reminder.userID, reminder.taskID, message, now)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("UPDATE task_reminders SET fired_at = $1 WHERE id = $2", now, reminder.id); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(due), tx.Commit()
+}
@@ -0,0 +1,465 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"maps"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// A revision is a task_logs entry. The task as it was at a revision is
+// rebuilt by undoing, newest first, every change logged after it.
+var revertibleFields = map[string]bool{
+	"title":           true,
+	"description":     true,
+	"column_id":       true,
+	"parent_id":       true,
+	"estimate_points": true,
+	"estimate_hours":  true,
+	"assignee_id":     true,
+	"labels":          true,
+}
+
+func isRevertible(field string) bool {
+	return revertibleFields[field] || strings.HasPrefix(field, customFieldPrefix)
+}
+
+// normalizeValue round-trips a value through JSON so values read from the
+// database and values built in Go compare the same way.
+func normalizeValue(value any) any {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized any
+	json.Unmarshal(encoded, &normalized)
+	if list, ok := normalized.([]any); ok {
+		sort.Slice(list, func(i, j int) bool { return fmt.Sprint(list[i]) < fmt.Sprint(list[j]) })
+	}
+	return normalized
+}
+
+func sameValue(a, b any) bool {
+	aJSON, _ := json.Marshal(normalizeValue(a))
+	bJSON, _ := json.Marshal(normalizeValue(b))
+	return bytes.Equal(aJSON, bJSON)
+}
+
+func taskSnapshot(task types.Task) map[string]any {
+	labels := task.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	snapshot := map[string]any{
+		"title":           task.Title,
+		"description":     task.Description,
+		"column_id":       task.ColumnID,
+		"parent_id":       task.ParentID,
+		"estimate_points": task.EstimatePoints,
+		"estimate_hours":  task.EstimateHours,
+		"assignee_id":     task.AssigneeID,
+		"labels":          labels,
+	}
+	for name, value := range task.CustomFields {
+		snapshot[customFieldPrefix+name] = value
+	}
+	for field, value := range snapshot {
+		snapshot[field] = normalizeValue(value)
+	}
+	return snapshot
+}
+
+func latestRevision(db querier, taskID int) (int, error) {
+	var revisionID int
+	err := db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM task_logs WHERE task_id = $1", taskID).Scan(&revisionID)
+	return revisionID, err
+}
+
+// revisionPreview returns the current task together with the changes that
+// reverting it to the given revision would make.
+func (app *App) revisionPreview(taskID, revisionID int) (types.TaskRevision, types.Task, error) {
+	preview := types.TaskRevision{RevisionID: revisionID}
+
+	var task types.Task
+	err := scanTask(app.DB.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND deleted_at IS NULL", taskID), &task)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return preview, task, &apiError{http.StatusNotFound, "Task not found"}
+		}
+		return preview, task, err
+	}
+	tasks := []types.Task{task}
+	if err := app.enrichTasks(tasks); err != nil {
+		return preview, task, err
+	}
+	task = tasks[0]
+
+	var exists bool
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM task_logs WHERE id = $1 AND task_id = $2)", revisionID, taskID).Scan(&exists)
+	if err != nil {
+		return preview, task, err
+	}
+	if !exists {
+		return preview, task, &apiError{http.StatusNotFound, "Revision not found"}
+	}
+
+	rows, err := app.DB.Query("SELECT id, changes FROM task_logs WHERE task_id = $1 AND id > $2 ORDER BY id DESC", taskID, revisionID)
+	if err != nil {
+		return preview, task, err
+	}
+	defer rows.Close()
+
+	current := taskSnapshot(task)
+	target := make(map[string]any, len(current))
+	for field, value := range current {
+		target[field] = value
+	}
+
+	preview.CurrentRevision = revisionID
+	for rows.Next() {
+		var logID int
+		var raw []byte
+		if err := rows.Scan(&logID, &raw); err != nil {
+			return preview, task, err
+		}
+		if logID > preview.CurrentRevision {
+			preview.CurrentRevision = logID
+		}
+
+		var changes []types.FieldChange
+		if err := json.Unmarshal(raw, &changes); err != nil {
+			return preview, task, err
+		}
+		for i := len(changes) - 1; i >= 0; i-- {
+			if isRevertible(changes[i].Field) {
+				target[changes[i].Field] = normalizeValue(changes[i].Old)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return preview, task, err
+	}
+
+	fields := make([]string, 0, len(target))
+	for field := range target {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	preview.Fields = make(map[string]any, len(target))
+	preview.Changes = []types.FieldChange{}
+	for _, field := range fields {
+		if strings.HasPrefix(field, customFieldPrefix) && target[field] == nil {
+			// Custom fields without a value at the revision are cleared, not set to null.
+			if current[field] == nil {
+				continue
+			}
+		} else {
+			preview.Fields[field] = target[field]
+		}
+		if !sameValue(current[field], target[field]) {
+			preview.Changes = append(preview.Changes, types.FieldChange{Field: field, Old: current[field], New: target[field]})
+		}
+	}
+	return preview, task, nil
+}
+
+func (app *App) GetTaskRevision(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+	revisionID, err := strconv.Atoi(chi.URLParam(r, "revisionID"))
+	if err != nil {
+		http.Error(w, "Invalid revision ID", http.StatusBadRequest)
+		return
+	}
+
+	preview, _, err := app.revisionPreview(taskID, revisionID)
+	if err != nil {
+		respondError(w, err, "Database error while building revision")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+func (app *App) RevertTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+
+	var revert types.TaskRevert
+	err = json.NewDecoder(r.Body).Decode(&revert)
+	if err != nil || revert.RevisionID == 0 {
+		http.Error(w, "Invalid request payload or missing revision_id", http.StatusBadRequest)
+		return
+	}
+
+	preview, task, err := app.revisionPreview(taskID, revert.RevisionID)
+	if err != nil {
+		respondError(w, err, "Database error while building revision")
+		return
+	}
+	if revert.ExpectedRevision != 0 && revert.ExpectedRevision != preview.CurrentRevision {
+		http.Error(w, "Task has changed since the revision was previewed", http.StatusConflict)
+		return
+	}
+	if len(preview.Changes) == 0 {
+		http.Error(w, "Task already matches this revision", http.StatusConflict)
+		return
+	}
+
+	projectID, err := app.columnProjectID(task.ColumnID)
+	if err != nil {
+		http.Error(w, "Database error while fetching project", http.StatusInternalServerError)
+		return
+	}
+
+	reverted := task
+	var labels []string
+	customValues := map[string]any{}
+	for _, change := range preview.Changes {
+		if err := applyRevisionValue(&reverted, &labels, customValues, change); err != nil {
+			respondError(w, err, "Invalid revision")
+			return
+		}
+	}
+
+	if reverted.ColumnID != task.ColumnID {
+		targetProjectID, err := app.columnProjectID(reverted.ColumnID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "The column of this revision no longer exists", http.StatusConflict)
+				return
+			}
+			http.Error(w, "Database error while checking column", http.StatusInternalServerError)
+			return
+		}
+		if targetProjectID != projectID {
+			http.Error(w, "The column of this revision belongs to another project", http.StatusConflict)
+			return
+		}
+
+		var targetCategory string
+		err = app.DB.QueryRow("SELECT category FROM columns WHERE id = $1", reverted.ColumnID).Scan(&targetCategory)
+		if err != nil {
+			http.Error(w, "Database error while checking column", http.StatusInternalServerError)
+			return
+		}
+		if app.EnforceBlockers && targetCategory == CategoryDone {
+			tasks := []types.Task{task}
+			if err := app.enrichTasks(tasks); err != nil {
+				http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+				return
+			}
+			if hasUnresolvedBlockers(tasks[0]) {
+				http.Error(w, "Task has unresolved blockers", http.StatusConflict)
+				return
+			}
+		}
+	}
+	if reverted.ParentID != nil && !sameValue(reverted.ParentID, task.ParentID) {
+		if err := app.checkParent(task.ID, reverted.ColumnID, *reverted.ParentID); err != nil {
+			respondError(w, err, "Database error while checking parent task")
+			return
+		}
+	}
+	if reverted.AssigneeID != nil && !sameValue(reverted.AssigneeID, task.AssigneeID) {
+		if err := app.checkProjectMember(projectID, *reverted.AssigneeID); err != nil {
+			respondError(w, err, "Database error while checking assignee")
+			return
+		}
+	}
+	validated, err := validateCustomValues(app.DB, projectID, customValues, false)
+	if err != nil {
+		respondError(w, err, "Database error while checking custom fields")
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SELECT id FROM tasks WHERE id = $1 FOR UPDATE", task.ID)
+	if err != nil {
+		http.Error(w, "Database error while locking task", http.StatusInternalServerError)
+		return
+	}
+	latest, err := latestRevision(tx, task.ID)
+	if err != nil {
+		http.Error(w, "Database error while checking revision", http.StatusInternalServerError)
+		return
+	}
+	if latest != preview.CurrentRevision {
+		http.Error(w, "Task has changed since the revision was previewed", http.StatusConflict)
+		return
+	}
+
+	var warning string
+	if reverted.ColumnID != task.ColumnID {
+		// Required fields are checked against the task as the revert leaves it.
+		target := reverted
+		target.ColumnID = task.ColumnID
+		if labels != nil {
+			target.Labels = labels
+		}
+		target.CustomFields = maps.Clone(task.CustomFields)
+		for name, value := range customValues {
+			if target.CustomFields == nil {
+				target.CustomFields = map[string]any{}
+			}
+			target.CustomFields[name] = value
+		}
+		if err := checkTransition(tx, target, reverted.ColumnID, app.optionalUserID(r)); err != nil {
+			respondError(w, err, "Database error while checking workflow")
+			return
+		}
+		warning, err = admitToColumn(tx, reverted.ColumnID)
+		if err != nil {
+			respondError(w, err, "Database error while checking WIP limit")
+			return
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE tasks SET title = $1, description = $2, parent_id = $3,
+		estimate_points = $4, estimate_hours = $5, assignee_id = $6 WHERE id = $7`,
+		reverted.Title, reverted.Description, reverted.ParentID,
+		reverted.EstimatePoints, reverted.EstimateHours, reverted.AssigneeID, task.ID)
+	if err != nil {
+		http.Error(w, "Error reverting task", http.StatusInternalServerError)
+		return
+	}
+	if err := storeDescriptionHTML(tx, "tasks", task.ID, reverted.Description); err != nil {
+		http.Error(w, "Error rendering task description", http.StatusInternalServerError)
+		return
+	}
+	if reverted.ColumnID != task.ColumnID {
+		err = tx.QueryRow("UPDATE tasks SET column_id = $1, "+movedPlacement+" WHERE id = $2 RETURNING position, swimlane_id",
+			reverted.ColumnID, task.ID).Scan(&reverted.Position, &reverted.SwimlaneID)
+		if err != nil {
+			http.Error(w, "Error reverting task", http.StatusInternalServerError)
+			return
+		}
+		if err := trackCategory(tx, &reverted, time.Now().UTC()); err != nil {
+			http.Error(w, "Error updating task timestamps", http.StatusInternalServerError)
+			return
+		}
+	}
+	if labels != nil {
+		if err := setTaskLabels(tx, task.ID, labels); err != nil {
+			http.Error(w, "Error saving task labels", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := saveCustomValues(tx, task.ID, validated); err != nil {
+		http.Error(w, "Error saving custom fields", http.StatusInternalServerError)
+		return
+	}
+
+	err = logTaskAction(tx, task.ID, app.optionalUserID(r), "revert",
+		fmt.Sprintf("Task reverted to revision %d", revert.RevisionID), preview.Changes...)
+	if err != nil {
+		http.Error(w, "Error logging task revert", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error reverting task", http.StatusInternalServerError)
+		return
+	}
+
+	tasks := []types.Task{reverted}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+	if warning != "" {
+		tasks[0].Warnings = []string{warning}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks[0])
+}
+
+func applyRevisionValue(task *types.Task, labels *[]string, customValues map[string]any, change types.FieldChange) error {
+	invalid := &apiError{http.StatusConflict, fmt.Sprintf("Revision has an invalid value for %s", change.Field)}
+
+	if name, ok := strings.CutPrefix(change.Field, customFieldPrefix); ok {
+		customValues[name] = change.New
+		return nil
+	}
+
+	switch change.Field {
+	case "title", "description":
+		text, ok := change.New.(string)
+		if !ok {
+			return invalid
+		}
+		if change.Field == "title" {
+			task.Title = text
+		} else {
+			task.Description = text
+		}
+	case "column_id":
+		number, ok := change.New.(float64)
+		if !ok {
+			return invalid
+		}
+		task.ColumnID = int(number)
+	case "parent_id", "estimate_points", "assignee_id":
+		var value *int
+		if change.New != nil {
+			number, ok := change.New.(float64)
+			if !ok {
+				return invalid
+			}
+			converted := int(number)
+			value = &converted
+		}
+		switch change.Field {
+		case "parent_id":
+			task.ParentID = value
+		case "estimate_points":
+			task.EstimatePoints = value
+		default:
+			task.AssigneeID = value
+		}
+	case "estimate_hours":
+		task.EstimateHours = nil
+		if change.New != nil {
+			number, ok := change.New.(float64)
+			if !ok {
+				return invalid
+			}
+			task.EstimateHours = &number
+		}
+	case "labels":
+		list, _ := change.New.([]any)
+		*labels = make([]string, 0, len(list))
+		for _, item := range list {
+			text, ok := item.(string)
+			if !ok {
+				return invalid
+			}
+			*labels = append(*labels, text)
+		}
+	}
+	return nil
+}
@@ -14,10 +14,43 @@ func InitRouter(app *App) *chi.Mux {
 
 	r.Route("/projects", func(r chi.Router) {
 		r.Get("/", app.GetProjects)
+		r.Get("/trash", app.GetProjectsTrash)
 		r.Get("/{id}", app.GetProjectByID)
 		r.Post("/create", app.CreateProjectHandler)
 		r.Put("/{id}", app.UpdateProjectHandler)
 		r.Delete("/{id}", app.DeleteProjectHandler)
+		r.Post("/{id}/restore", app.RestoreProjectHandler)
+		r.Post("/{id}/clone", app.CloneProjectHandler)
+		r.Get("/{id}/trash", app.GetProjectTrash)
+		r.Get("/{id}/tasks/similar", app.GetProjectSimilarTasks)
+
+		r.Get("/{id}/fields", app.GetCustomFields)
+		r.Post("/{id}/fields", app.CreateCustomFieldHandler)
+
+		r.Get("/{id}/templates", app.GetTaskTemplates)
+		r.Post("/{id}/templates", app.CreateTaskTemplateHandler)
+
+		r.Get("/{id}/board_templates", app.GetBoardTemplates)
+		r.Post("/{id}/board_templates", app.CreateBoardTemplateHandler)
+	})
+
+	r.Route("/board_templates", func(r chi.Router) {
+		r.Get("/{id}", app.GetBoardTemplateByID)
+		r.Put("/{id}", app.UpdateBoardTemplateHandler)
+		r.Delete("/{id}", app.DeleteBoardTemplateHandler)
+	})
+
+	r.Route("/templates", func(r chi.Router) {
+		r.Get("/{id}", app.GetTaskTemplateByID)
+		r.Put("/{id}", app.UpdateTaskTemplateHandler)
+		r.Delete("/{id}", app.DeleteTaskTemplateHandler)
+		r.Delete("/{id}/recurrence", app.StopTaskTemplateRecurrenceHandler)
+		r.Post("/{id}/create", app.CreateTaskFromTemplateHandler)
+	})
+
+	r.Route("/fields", func(r chi.Router) {
+		r.Put("/{id}", app.UpdateCustomFieldHandler)
+		r.Delete("/{id}", app.DeleteCustomFieldHandler)
 	})
 
 	r.Route("/project_users", func(r chi.Router) {
@@ -32,6 +65,25 @@ func InitRouter(app *App) *chi.Mux {
 		r.Post("/create", app.CreateBoardHandler)
 		r.Put("/{id}", app.UpdateBoardNameHandler)
 		r.Delete("/{id}", app.DeleteBoardHandler)
+		r.Post("/{id}/restore", app.RestoreBoardHandler)
+		r.Post("/{id}/clone", app.CloneBoardHandler)
+		r.Post("/{id}/template", app.SaveBoardAsTemplateHandler)
+		r.Post("/{id}/archive", app.ArchiveBoardHandler)
+		r.Post("/{id}/unarchive", app.UnarchiveBoardHandler)
+		r.Get("/{id}/columns", app.GetBoardColumns)
+		r.Put("/{id}/columns/order", app.ReorderColumnsHandler)
+		r.Get("/{id}/swimlanes", app.GetSwimlanes)
+		r.Post("/{id}/swimlanes", app.CreateSwimlaneHandler)
+		r.Put("/{id}/swimlanes/order", app.ReorderSwimlanesHandler)
+		r.Put("/{id}/swimlanes/field", app.SetSwimlaneFieldHandler)
+		r.Get("/{id}/layout", app.GetBoardLayout)
+		r.Get("/{id}/workflow", app.GetWorkflow)
+		r.Put("/{id}/workflow", app.UpdateWorkflowHandler)
+	})
+
+	r.Route("/swimlanes", func(r chi.Router) {
+		r.Put("/{id}", app.UpdateSwimlaneHandler)
+		r.Delete("/{id}", app.DeleteSwimlaneHandler)
 	})
 
 	r.Route("/columns", func(r chi.Router) {
@@ -40,16 +92,81 @@ func InitRouter(app *App) *chi.Mux {
 		r.Post("/create", app.CreateColumnHandler)
 		r.Put("/{id}", app.UpdateColumnHandler)
 		r.Delete("/{id}", app.DeleteColumnHandler)
+		r.Post("/{id}/restore", app.RestoreColumnHandler)
+		r.Post("/{id}/archive", app.ArchiveColumnTasksHandler)
+		r.Put("/{id}/wip", app.UpdateColumnWIPHandler)
+		r.Put("/{id}/category", app.UpdateColumnCategoryHandler)
 	})
 
 	r.Route("/tasks", func(r chi.Router) {
 		r.Get("/", app.GetTasks)
+		r.Get("/watched", app.GetWatchedTasks)
 		r.Get("/{id}", app.GetTaskByID)
 		r.Get("/column/{id}", app.GetTasksByColumn)
 		r.Get("/{id}/logs", app.GetTaskLogs)
+		r.Get("/{id}/revisions/{revisionID}", app.GetTaskRevision)
+		r.Post("/{id}/revert", app.RevertTaskHandler)
 		r.Post("/create", app.CreateTaskHandler)
+		r.Post("/bulk", app.BulkTaskHandler)
 		r.Put("/{id}", app.UpdateTaskHandler)
+		r.Put("/{id}/move", app.MoveTaskHandler)
+		r.Put("/{id}/placement", app.PlaceTaskHandler)
+		r.Put("/{id}/parent", app.SetTaskParentHandler)
+		r.Get("/{id}/tree", app.GetTaskTree)
 		r.Delete("/{id}", app.DeleteTaskHandler)
+		r.Post("/{id}/restore", app.RestoreTaskHandler)
+		r.Post("/{id}/clone", app.CloneTaskHandler)
+		r.Get("/{id}/similar", app.GetSimilarTasks)
+		r.Post("/{id}/archive", app.ArchiveTaskHandler)
+		r.Post("/{id}/unarchive", app.UnarchiveTaskHandler)
+
+		r.Get("/{id}/relations", app.GetTaskRelations)
+		r.Post("/{id}/relations", app.CreateTaskRelationHandler)
+		r.Delete("/{id}/relations/{relationID}", app.DeleteTaskRelationHandler)
+
+		r.Get("/{id}/worklogs", app.GetTaskWorklogs)
+		r.Post("/{id}/worklogs", app.CreateWorklogHandler)
+
+		r.Get("/{id}/watchers", app.GetTaskWatchers)
+		r.Post("/{id}/watch", app.WatchTaskHandler)
+		r.Delete("/{id}/watch", app.UnwatchTaskHandler)
+
+		r.Post("/{id}/reactions", app.AddReactionHandler)
+		r.Delete("/{id}/reactions/{emoji}", app.RemoveReactionHandler)
+		r.Post("/{id}/vote", app.VoteTaskHandler)
+		r.Delete("/{id}/vote", app.UnvoteTaskHandler)
+
+		r.Get("/{id}/links", app.GetTaskLinks)
+		r.Post("/{id}/links", app.CreateTaskLinkHandler)
+		r.Delete("/{id}/links/{linkID}", app.DeleteTaskLinkHandler)
+
+		r.Get("/{id}/reminders", app.GetTaskReminders)
+		r.Post("/{id}/reminders", app.CreateReminderHandler)
+
+		r.Post("/{id}/checklist", app.CreateChecklistItemHandler)
+	})
+
+	r.Route("/checklist", func(r chi.Router) {
+		r.Put("/{id}", app.UpdateChecklistItemHandler)
+		r.Delete("/{id}", app.DeleteChecklistItemHandler)
+	})
+
+	r.Post("/git/events", app.GitEventHandler)
+
+	r.Route("/reminders", func(r chi.Router) {
+		r.Get("/", app.GetReminders)
+		r.Delete("/{id}", app.DeleteReminderHandler)
+	})
+
+	r.Route("/notifications", func(r chi.Router) {
+		r.Get("/", app.GetNotifications)
+		r.Put("/{id}/read", app.MarkNotificationReadHandler)
+	})
+
+	r.Route("/worklogs", func(r chi.Router) {
+		r.Get("/timesheet/{userID}", app.GetTimesheet)
+		r.Get("/export", app.ExportWorklogsCSV)
+		r.Delete("/{id}", app.DeleteWorklogHandler)
 	})
 
 	return r
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"kanban-board/types"
+)
+
+const recurrenceBatchSize = 50
+
+// StartRecurrenceScheduler materializes due recurring templates every interval.
+// Each run is recorded in task_template_runs, so an occurrence is created at
+// most once even across restarts or several server instances.
+func (app *App) StartRecurrenceScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := app.runDueRecurrences(time.Now().UTC()); err != nil {
+				log.Printf("Error running recurring tasks: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func (app *App) runDueRecurrences(now time.Time) error {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT "+templateColumns+` FROM task_templates
+		WHERE rrule <> '' AND next_run_at <= $1
+		AND column_id IN (SELECT id FROM columns WHERE deleted_at IS NULL)
+		ORDER BY next_run_at LIMIT $2 FOR UPDATE SKIP LOCKED`, now, recurrenceBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var templates []types.TaskTemplate
+	for rows.Next() {
+		var template types.TaskTemplate
+		if err := scanTemplate(rows, &template); err != nil {
+			rows.Close()
+			return err
+		}
+		templates = append(templates, template)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, template := range templates {
+		// Each template runs under its own savepoint, so one failing template
+		// is disabled without rolling back the rest of the batch.
+		if _, err := tx.Exec("SAVEPOINT recurrence"); err != nil {
+			return err
+		}
+		if err := runRecurrence(tx, template, now); err != nil {
+			log.Printf("Disabling template %d after failed recurrence: %v", template.ID, err)
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT recurrence"); err != nil {
+				return err
+			}
+			if _, err := tx.Exec("UPDATE task_templates SET next_run_at = NULL WHERE id = $1", template.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func runRecurrence(tx querier, template types.TaskTemplate, now time.Time) error {
+	rule, err := parseRRule(template.RRule)
+	if err != nil {
+		return fmt.Errorf("invalid recurrence rule: %w", err)
+	}
+
+	// Occurrences missed while the server was down are skipped; only the
+	// latest due one is created.
+	occurrence := *template.NextRunAt
+	for {
+		next, ok := rule.next(*template.StartsAt, occurrence, template.Occurrences+1)
+		if !ok || next.After(now) {
+			break
+		}
+		occurrence = next
+	}
+
+	result, err := tx.Exec(`INSERT INTO task_template_runs (template_id, occurrence_at) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, template.ID, occurrence)
+	if err != nil {
+		return err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if inserted == 1 {
+		// A column gated by the workflow, at its hard WIP limit or on an
+		// archived board skips this occurrence but keeps the schedule.
+		err := checkEntry(tx, *template.ColumnID)
+		if err == nil {
+			_, err = admitToColumn(tx, *template.ColumnID)
+		}
+		if apiErr, ok := err.(*apiError); ok && apiErr.Status == http.StatusConflict {
+			log.Printf("Skipping occurrence of template %d: %s", template.ID, apiErr.Message)
+		} else if err != nil {
+			return err
+		} else {
+			task, err := instantiateTemplate(tx, template, *template.ColumnID, 0, occurrence, nil)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec("UPDATE task_template_runs SET task_id = $1 WHERE template_id = $2 AND occurrence_at = $3",
+				task.ID, template.ID, occurrence)
+			if err != nil {
+				return err
+			}
+			log.Printf("Recurring task %d created from template %d", task.ID, template.ID)
+		}
+		template.Occurrences++
+	}
+
+	var nextRunAt *time.Time
+	if next, ok := rule.next(*template.StartsAt, occurrence, template.Occurrences); ok {
+		nextRunAt = &next
+	}
+	_, err = tx.Exec("UPDATE task_templates SET next_run_at = $1, occurrences = $2 WHERE id = $3",
+		nextRunAt, template.Occurrences, template.ID)
+	return err
+}
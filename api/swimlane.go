@@ -0,0 +1,703 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const (
+	LaneAssignee = "assignee"
+	LaneLabel    = "label"
+	LaneEpic     = "epic"
+	LanePriority = "priority"
+
+	noLaneKey = "none"
+)
+
+// movedPlacement resets the placement of a task moving to column $1: it goes
+// to the end of the new column and keeps its swimlane only within the board.
+const movedPlacement = `position = NULL, swimlane_id = (SELECT swimlanes.id FROM swimlanes
+	JOIN columns ON columns.board_id = swimlanes.board_id
+	WHERE swimlanes.id = tasks.swimlane_id AND columns.id = $1)`
+
+// laneField describes what the lanes of a board are derived from. An empty
+// name means the board uses its explicit swimlanes.
+type laneField struct {
+	name        string
+	customField string
+	fieldType   string
+	options     []string
+}
+
+func boardLaneSettings(db querier, boardID int) (int, string, error) {
+	var projectID int
+	var field string
+	err := db.QueryRow("SELECT project_id, swimlane_field FROM boards WHERE id = $1 AND deleted_at IS NULL", boardID).
+		Scan(&projectID, &field)
+	if err == sql.ErrNoRows {
+		return 0, "", &apiError{http.StatusNotFound, "Board not found"}
+	}
+	return projectID, field, err
+}
+
+// resolveLaneField checks a swimlane field against the board's project.
+// "priority" is shorthand for a custom field named priority, since priorities
+// are modelled as custom fields.
+func (app *App) resolveLaneField(projectID int, name string) (laneField, error) {
+	switch name {
+	case "", LaneAssignee, LaneLabel, LaneEpic:
+		return laneField{name: name}, nil
+	}
+
+	fieldName, ok := strings.CutPrefix(name, customFieldPrefix)
+	if name == LanePriority {
+		fieldName, ok = LanePriority, true
+	}
+	if !ok || fieldName == "" {
+		return laneField{}, &apiError{http.StatusBadRequest, "Swimlane field must be one of: assignee, label, epic, priority, cf.<name>"}
+	}
+
+	field := laneField{name: name, customField: fieldName}
+	err := app.DB.QueryRow("SELECT type, options FROM custom_fields WHERE project_id = $1 AND name = $2",
+		projectID, fieldName).Scan(&field.fieldType, pq.Array(&field.options))
+	if err == sql.ErrNoRows {
+		return laneField{}, &apiError{http.StatusBadRequest, fmt.Sprintf("Custom field %q does not exist", fieldName)}
+	}
+	return field, err
+}
+
+func boardSwimlanes(db querier, boardID int) ([]types.Swimlane, error) {
+	rows, err := db.Query(`SELECT id, board_id, name, position, created_at FROM swimlanes
+		WHERE board_id = $1 ORDER BY position, id`, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	swimlanes := []types.Swimlane{}
+	for rows.Next() {
+		var swimlane types.Swimlane
+		if err := rows.Scan(&swimlane.ID, &swimlane.BoardID, &swimlane.Name, &swimlane.Position, &swimlane.CreatedAt); err != nil {
+			return nil, err
+		}
+		swimlanes = append(swimlanes, swimlane)
+	}
+	return swimlanes, rows.Err()
+}
+
+func (app *App) GetSwimlanes(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+	if _, _, err := boardLaneSettings(app.DB, boardID); err != nil {
+		respondError(w, err, "Database error while fetching board")
+		return
+	}
+
+	swimlanes, err := boardSwimlanes(app.DB, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching swimlanes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(swimlanes)
+}
+
+func (app *App) CreateSwimlaneHandler(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+
+	var swimlane types.Swimlane
+	if err := json.NewDecoder(r.Body).Decode(&swimlane); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	swimlane.Name = strings.TrimSpace(swimlane.Name)
+	if swimlane.Name == "" || len([]rune(swimlane.Name)) > maxNameLength {
+		http.Error(w, "Swimlane name must be between 1 and 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	if _, _, err := boardLaneSettings(app.DB, boardID); err != nil {
+		respondError(w, err, "Database error while fetching board")
+		return
+	}
+
+	swimlane.BoardID = boardID
+	err = app.DB.QueryRow(`INSERT INTO swimlanes (board_id, name, position)
+		VALUES ($1, $2, (SELECT COALESCE(MAX(position) + 1, 0) FROM swimlanes WHERE board_id = $1))
+		RETURNING id, position, created_at`, boardID, swimlane.Name).Scan(&swimlane.ID, &swimlane.Position, &swimlane.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "This swimlane already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Error creating swimlane", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(swimlane)
+}
+
+func (app *App) UpdateSwimlaneHandler(w http.ResponseWriter, r *http.Request) {
+	swimlaneID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid swimlane ID", http.StatusBadRequest)
+		return
+	}
+
+	var updateData types.Swimlane
+	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	updateData.Name = strings.TrimSpace(updateData.Name)
+	if updateData.Name == "" || len([]rune(updateData.Name)) > maxNameLength {
+		http.Error(w, "Swimlane name must be between 1 and 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	var swimlane types.Swimlane
+	err = app.DB.QueryRow(`UPDATE swimlanes SET name = $1 WHERE id = $2
+		RETURNING id, board_id, name, position, created_at`, updateData.Name, swimlaneID).
+		Scan(&swimlane.ID, &swimlane.BoardID, &swimlane.Name, &swimlane.Position, &swimlane.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Swimlane not found", http.StatusNotFound)
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "This swimlane already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Error updating swimlane", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(swimlane)
+}
+
+func (app *App) DeleteSwimlaneHandler(w http.ResponseWriter, r *http.Request) {
+	swimlaneID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid swimlane ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.DB.Exec("DELETE FROM swimlanes WHERE id = $1", swimlaneID)
+	if err != nil {
+		http.Error(w, "Database error while deleting swimlane", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Swimlane not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Swimlane deleted successfully"))
+}
+
+func (app *App) ReorderSwimlanesHandler(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+
+	var order types.SwimlaneOrder
+	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, _, err := boardLaneSettings(tx, boardID); err != nil {
+		respondError(w, err, "Database error while fetching board")
+		return
+	}
+
+	_, err = tx.Exec("SELECT id FROM swimlanes WHERE board_id = $1 FOR UPDATE", boardID)
+	if err != nil {
+		http.Error(w, "Database error while locking swimlanes", http.StatusInternalServerError)
+		return
+	}
+	swimlanes, err := boardSwimlanes(tx, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching swimlanes", http.StatusInternalServerError)
+		return
+	}
+
+	onBoard := make(map[int]bool, len(swimlanes))
+	for _, swimlane := range swimlanes {
+		onBoard[swimlane.ID] = true
+	}
+	seen := make(map[int]bool, len(order.SwimlaneIDs))
+	for _, swimlaneID := range order.SwimlaneIDs {
+		if !onBoard[swimlaneID] || seen[swimlaneID] {
+			http.Error(w, "swimlane_ids must list each swimlane of the board exactly once", http.StatusBadRequest)
+			return
+		}
+		seen[swimlaneID] = true
+	}
+	if len(seen) != len(swimlanes) {
+		http.Error(w, "swimlane_ids must list each swimlane of the board exactly once", http.StatusBadRequest)
+		return
+	}
+
+	for position, swimlaneID := range order.SwimlaneIDs {
+		if _, err := tx.Exec("UPDATE swimlanes SET position = $1 WHERE id = $2", position, swimlaneID); err != nil {
+			http.Error(w, "Error reordering swimlanes", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	swimlanes, err = boardSwimlanes(tx, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching swimlanes", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error reordering swimlanes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(swimlanes)
+}
+
+func (app *App) SetSwimlaneFieldHandler(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+
+	var request types.SwimlaneField
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	projectID, _, err := boardLaneSettings(app.DB, boardID)
+	if err != nil {
+		respondError(w, err, "Database error while fetching board")
+		return
+	}
+	if _, err := app.resolveLaneField(projectID, request.Field); err != nil {
+		respondError(w, err, "Database error while checking swimlane field")
+		return
+	}
+
+	var board types.Board
+	err = app.DB.QueryRow(`UPDATE boards SET swimlane_field = $1 WHERE id = $2
+		RETURNING id, project_id, name, swimlane_field, archived_at`, request.Field, boardID).
+		Scan(&board.ID, &board.ProjectID, &board.Name, &board.SwimlaneField, &board.ArchivedAt)
+	if err != nil {
+		http.Error(w, "Error updating swimlane field", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+// PlaceTaskHandler puts a task into an explicit swimlane and/or at a position
+// within its column. Positions are shared by all lanes of a column, so the
+// relative order of tasks in each cell follows the column order.
+func (app *App) PlaceTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+
+	var placement types.TaskPlacement
+	if err := json.NewDecoder(r.Body).Decode(&placement); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if placement.SwimlaneID == nil && placement.Position == nil {
+		http.Error(w, "Either swimlane_id or position is required", http.StatusBadRequest)
+		return
+	}
+	if placement.Position != nil && *placement.Position < 0 {
+		http.Error(w, "Position cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var task types.Task
+	err = scanTask(tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", taskID), &task)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching task", http.StatusInternalServerError)
+		return
+	}
+
+	var changes []types.FieldChange
+	if placement.SwimlaneID != nil {
+		swimlaneID := placement.SwimlaneID
+		if *swimlaneID == 0 {
+			swimlaneID = nil
+		} else {
+			var onBoard bool
+			err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM swimlanes
+				JOIN columns ON columns.board_id = swimlanes.board_id
+				WHERE swimlanes.id = $1 AND columns.id = $2)`, *swimlaneID, task.ColumnID).Scan(&onBoard)
+			if err != nil {
+				http.Error(w, "Database error while checking swimlane", http.StatusInternalServerError)
+				return
+			}
+			if !onBoard {
+				http.Error(w, "Swimlane does not belong to the task's board", http.StatusBadRequest)
+				return
+			}
+		}
+
+		changes = recordChange(changes, "swimlane_id", task.SwimlaneID, swimlaneID)
+		if _, err := tx.Exec("UPDATE tasks SET swimlane_id = $1 WHERE id = $2", swimlaneID, task.ID); err != nil {
+			http.Error(w, "Error placing task", http.StatusInternalServerError)
+			return
+		}
+		task.SwimlaneID = swimlaneID
+	}
+
+	if placement.Position != nil {
+		rows, err := tx.Query(`SELECT id FROM tasks
+			WHERE column_id = $1 AND id <> $2 AND deleted_at IS NULL AND archived_at IS NULL
+			ORDER BY position NULLS LAST, id FOR UPDATE`, task.ColumnID, task.ID)
+		if err != nil {
+			http.Error(w, "Database error while fetching column tasks", http.StatusInternalServerError)
+			return
+		}
+		var order []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				http.Error(w, "Error scanning tasks", http.StatusInternalServerError)
+				return
+			}
+			order = append(order, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			http.Error(w, "Error scanning tasks", http.StatusInternalServerError)
+			return
+		}
+
+		position := min(*placement.Position, len(order))
+		order = slices.Insert(order, position, task.ID)
+		for i, id := range order {
+			if _, err := tx.Exec("UPDATE tasks SET position = $1 WHERE id = $2", i, id); err != nil {
+				http.Error(w, "Error placing task", http.StatusInternalServerError)
+				return
+			}
+		}
+		task.Position = &position
+	}
+
+	if len(changes) > 0 {
+		if err := logTaskAction(tx, task.ID, app.optionalUserID(r), "placement", "Task moved to another swimlane", changes...); err != nil {
+			http.Error(w, "Error logging task placement", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error placing task", http.StatusInternalServerError)
+		return
+	}
+
+	tasks := []types.Task{task}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks[0])
+}
+
+// GetBoardLayout returns the board as a grid of lanes by columns, with the
+// tasks of each cell in column order.
+func (app *App) GetBoardLayout(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+
+	projectID, fieldName, err := boardLaneSettings(app.DB, boardID)
+	if err != nil {
+		respondError(w, err, "Database error while fetching board")
+		return
+	}
+	field, err := app.resolveLaneField(projectID, fieldName)
+	if err != nil {
+		// The custom field behind the lanes was removed; fall back to explicit lanes.
+		if _, ok := err.(*apiError); !ok {
+			http.Error(w, "Database error while checking swimlane field", http.StatusInternalServerError)
+			return
+		}
+		field = laneField{}
+	}
+
+	columns, err := boardColumns(app.DB, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching columns", http.StatusInternalServerError)
+		return
+	}
+
+	tasks, err := app.listTasks(r, []string{"column_id IN (SELECT id FROM columns WHERE board_id = $1 AND deleted_at IS NULL)"},
+		[]any{boardID})
+	if err != nil {
+		respondError(w, err, "Database error while fetching tasks")
+		return
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i].Position, tasks[j].Position
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+
+	lanes, err := app.layoutLanes(boardID, field, tasks)
+	if err != nil {
+		http.Error(w, "Database error while building swimlanes", http.StatusInternalServerError)
+		return
+	}
+
+	laneIndex := make(map[string]int, len(lanes))
+	columnIndex := make(map[int]int, len(columns))
+	for i, column := range columns {
+		columnIndex[column.ID] = i
+	}
+	for i := range lanes {
+		laneIndex[lanes[i].Key] = i
+		lanes[i].Cells = make([]types.LayoutCell, len(columns))
+		for j, column := range columns {
+			lanes[i].Cells[j] = types.LayoutCell{ColumnID: column.ID, Tasks: []types.Task{}}
+		}
+	}
+	for _, task := range tasks {
+		key, _ := laneOf(field, task)
+		lane, ok := laneIndex[key]
+		if !ok {
+			// A task whose swimlane is no longer on the board shows without a lane.
+			lane = laneIndex[noLaneKey]
+		}
+		cell := &lanes[lane].Cells[columnIndex[task.ColumnID]]
+		cell.Tasks = append(cell.Tasks, task)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.BoardLayout{
+		BoardID:       boardID,
+		SwimlaneField: field.name,
+		Columns:       columns,
+		Lanes:         lanes,
+	})
+}
+
+// laneOf returns the lane key of a task and, for derived lanes, the raw
+// value naming the lane.
+func laneOf(field laneField, task types.Task) (string, string) {
+	switch field.name {
+	case "":
+		if task.SwimlaneID != nil {
+			return strconv.Itoa(*task.SwimlaneID), ""
+		}
+	case LaneAssignee:
+		if task.AssigneeID != nil {
+			return strconv.Itoa(*task.AssigneeID), ""
+		}
+	case LaneLabel:
+		if len(task.Labels) > 0 {
+			return "label:" + task.Labels[0], task.Labels[0]
+		}
+	case LaneEpic:
+		if task.ParentID != nil {
+			return strconv.Itoa(*task.ParentID), ""
+		}
+	default:
+		value := task.CustomFields[field.customField]
+		if values, ok := value.([]any); ok {
+			value = nil
+			if len(values) > 0 {
+				value = values[0]
+			}
+		}
+		if value != nil {
+			text := fmt.Sprint(value)
+			return "value:" + text, text
+		}
+	}
+	return noLaneKey, ""
+}
+
+// layoutLanes lists the lanes of a board in display order, each without cells.
+// Explicit lanes are all listed; derived lanes only for values that occur.
+// The lane for tasks without a value always comes last.
+func (app *App) layoutLanes(boardID int, field laneField, tasks []types.Task) ([]types.LayoutLane, error) {
+	var lanes []types.LayoutLane
+	hasNone := false
+	present := make(map[string]string)
+	for _, task := range tasks {
+		key, value := laneOf(field, task)
+		if key == noLaneKey {
+			hasNone = true
+			continue
+		}
+		present[key] = value
+	}
+
+	var ids []int64
+	for key := range present {
+		if id, err := strconv.Atoi(key); err == nil {
+			ids = append(ids, int64(id))
+		}
+	}
+
+	noneName := "No swimlane"
+	switch field.name {
+	case "":
+		swimlanes, err := boardSwimlanes(app.DB, boardID)
+		if err != nil {
+			return nil, err
+		}
+		for _, swimlane := range swimlanes {
+			id := swimlane.ID
+			lanes = append(lanes, types.LayoutLane{ID: &id, Key: strconv.Itoa(id), Name: swimlane.Name})
+		}
+		hasNone = true
+
+	case LaneAssignee:
+		names, err := app.laneNames("SELECT id, username FROM users WHERE id = ANY($1)", ids)
+		if err != nil {
+			return nil, err
+		}
+		lanes = derivedLanes(present, names)
+		noneName = "Unassigned"
+
+	case LaneEpic:
+		names, err := app.laneNames("SELECT id, title FROM tasks WHERE id = ANY($1)", ids)
+		if err != nil {
+			return nil, err
+		}
+		lanes = derivedLanes(present, names)
+		noneName = "No epic"
+
+	case LaneLabel:
+		lanes = derivedLanes(present, nil)
+		noneName = "No label"
+
+	default:
+		lanes = derivedLanes(present, nil)
+		if len(field.options) > 0 {
+			rank := make(map[string]int, len(field.options))
+			for i, option := range field.options {
+				rank[option] = i
+			}
+			sort.SliceStable(lanes, func(i, j int) bool {
+				a, aok := rank[lanes[i].Name]
+				b, bok := rank[lanes[j].Name]
+				if aok != bok {
+					return aok
+				}
+				return a < b
+			})
+		}
+		noneName = "No " + field.customField
+	}
+
+	if hasNone {
+		lanes = append(lanes, types.LayoutLane{Key: noLaneKey, Name: noneName})
+	}
+	return lanes, nil
+}
+
+func (app *App) laneNames(query string, ids []int64) (map[string]string, error) {
+	names := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	rows, err := app.DB.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[strconv.Itoa(id)] = name
+	}
+	return names, rows.Err()
+}
+
+// derivedLanes builds one lane per present key, named from names when given
+// and from the lane value otherwise, sorted by name.
+func derivedLanes(present map[string]string, names map[string]string) []types.LayoutLane {
+	lanes := make([]types.LayoutLane, 0, len(present))
+	for key, value := range present {
+		name := value
+		if names != nil {
+			name = names[key]
+		}
+		lanes = append(lanes, types.LayoutLane{Key: key, Name: name})
+	}
+	sort.Slice(lanes, func(i, j int) bool {
+		if lanes[i].Name != lanes[j].Name {
+			return lanes[i].Name < lanes[j].Name
+		}
+		return lanes[i].Key < lanes[j].Key
+	})
+	return lanes
+}
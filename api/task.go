@@ -1,15 +1,130 @@
 package api
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"kanban-board/types"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
+const taskColumns = "id, column_id, title, description, created_at, parent_id, estimate_points, estimate_hours, created_by, assignee_id, archived_at, " +
+	taskKeyExpr + ", tasks.swimlane_id, tasks.position, tasks.started_at, tasks.completed_at"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row scanner, task *types.Task, extra ...any) error {
+	dest := []any{&task.ID, &task.ColumnID, &task.Title, &task.Description, &task.CreatedAt, &task.ParentID,
+		&task.EstimatePoints, &task.EstimateHours, &task.CreatedBy, &task.AssigneeID, &task.ArchivedAt, &task.Key,
+		&task.SwimlaneID, &task.Position, &task.StartedAt, &task.CompletedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if task.ArchivedAt != nil {
+		archivedAt := task.ArchivedAt.UTC()
+		task.ArchivedAt = &archivedAt
+	}
+	if task.StartedAt != nil {
+		startedAt := task.StartedAt.UTC()
+		task.StartedAt = &startedAt
+	}
+	if task.CompletedAt != nil {
+		completedAt := task.CompletedAt.UTC()
+		task.CompletedAt = &completedAt
+	}
+	return nil
+}
+
+func validEstimates(task types.Task) bool {
+	if task.EstimatePoints != nil && *task.EstimatePoints < 0 {
+		return false
+	}
+	if task.EstimateHours != nil && *task.EstimateHours < 0 {
+		return false
+	}
+	return true
+}
+
+func (app *App) taskProjectID(taskID int) (int, error) {
+	var projectID int
+	err := app.DB.QueryRow(`SELECT boards.project_id FROM tasks
+		JOIN columns ON columns.id = tasks.column_id
+		JOIN boards ON boards.id = columns.board_id
+		WHERE tasks.id = $1 AND tasks.deleted_at IS NULL`, taskID).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		return 0, &apiError{http.StatusNotFound, "Task not found"}
+	}
+	return projectID, err
+}
+
+// logTaskAction records a task_logs entry and notifies every watcher of the
+// task except the user who performed the action.
+func logTaskAction(db querier, taskID, actorID int, actionType, logMessage string, changes ...types.FieldChange) error {
+	var actor *int
+	if actorID != 0 {
+		actor = &actorID
+	}
+	if changes == nil {
+		changes = []types.FieldChange{}
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`INSERT INTO task_logs (task_id, actor_id, action_type, log_message, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		taskID, actor, actionType, logMessage, string(changesJSON), time.Now().UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`INSERT INTO notifications (user_id, task_id, action_type, message)
+		SELECT user_id, task_id, $2, $3 FROM task_watchers WHERE task_id = $1 AND user_id <> $4`,
+		taskID, actionType, logMessage, actorID)
+	return err
+}
+
+// recordChange appends a field change unless the old and new values are equal.
+func recordChange(changes []types.FieldChange, field string, oldValue, newValue any) []types.FieldChange {
+	oldJSON, _ := json.Marshal(oldValue)
+	newJSON, _ := json.Marshal(newValue)
+	if bytes.Equal(oldJSON, newJSON) {
+		return changes
+	}
+	return append(changes, types.FieldChange{Field: field, Old: oldValue, New: newValue})
+}
+
+func (app *App) enrichTasks(tasks []types.Task) error {
+	if err := app.attachBlockers(tasks); err != nil {
+		return err
+	}
+	if err := app.attachProgress(tasks); err != nil {
+		return err
+	}
+	if err := app.attachLabelsAndChecklists(tasks); err != nil {
+		return err
+	}
+	if err := app.attachCustomValues(tasks); err != nil {
+		return err
+	}
+	if err := app.attachReactions(tasks); err != nil {
+		return err
+	}
+	return app.attachDescriptionHTML(tasks)
+}
+
 func (app *App) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	var task types.Task
 
@@ -24,53 +139,179 @@ func (app *App) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var columnExists bool
-	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM columns WHERE id = $1)", task.ColumnID).Scan(&columnExists)
+	projectID, err := app.columnProjectID(task.ColumnID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Column does not exist", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Database error while checking column", http.StatusInternalServerError)
 		return
 	}
-	if !columnExists {
-		http.Error(w, "Column does not exist", http.StatusNotFound)
+
+	if task.ParentID != nil {
+		if err := app.checkParent(0, task.ColumnID, *task.ParentID); err != nil {
+			respondError(w, err, "Database error while checking parent task")
+			return
+		}
+	}
+
+	if !validEstimates(task) {
+		http.Error(w, "Estimates cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	customValues, err := validateCustomValues(app.DB, projectID, task.CustomFields, true)
+	if err != nil {
+		respondError(w, err, "Database error while checking custom fields")
+		return
+	}
+
+	if task.AssigneeID != nil {
+		if err := app.checkProjectMember(projectID, *task.AssigneeID); err != nil {
+			respondError(w, err, "Database error while checking assignee")
+			return
+		}
+	}
+
+	labels, err := normalizeLabels(task.Labels)
+	if err != nil {
+		respondError(w, err, "Invalid labels")
 		return
 	}
 
-	var existingTitle string
-	err = app.DB.QueryRow("SELECT title FROM tasks WHERE title = $1", task.Title).Scan(&existingTitle)
-	if err != nil && err != sql.ErrNoRows {
+	if !app.checkDuplicates(w, r, projectID, task.Title) {
+		return
+	}
+
+	actorID := app.optionalUserID(r)
+	task.CreatedBy = nil
+	if actorID != 0 {
+		task.CreatedBy = &actorID
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
-	if existingTitle != "" {
-		http.Error(w, "This task already exists", http.StatusConflict)
+	defer tx.Rollback()
+
+	if err := checkEntry(tx, task.ColumnID); err != nil {
+		respondError(w, err, "Database error while checking workflow")
+		return
+	}
+	warning, err := admitToColumn(tx, task.ColumnID)
+	if err != nil {
+		respondError(w, err, "Database error while checking WIP limit")
 		return
 	}
 
-	err = app.DB.QueryRow("INSERT INTO tasks (column_id, title, description) VALUES ($1, $2, $3) RETURNING id, created_at",
-		task.ColumnID, task.Title, task.Description).Scan(&task.ID, &task.CreatedAt)
+	number, key, err := nextTaskNumber(tx, projectID)
+	if err != nil {
+		http.Error(w, "Error allocating task number", http.StatusInternalServerError)
+		return
+	}
+	task.Key = key
+
+	err = tx.QueryRow(`INSERT INTO tasks (column_id, title, description, parent_id, estimate_points, estimate_hours, created_by, assignee_id, number)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`,
+		task.ColumnID, task.Title, task.Description, task.ParentID, task.EstimatePoints, task.EstimateHours,
+		task.CreatedBy, task.AssigneeID, number).Scan(&task.ID, &task.CreatedAt)
 	if err != nil {
 		http.Error(w, "Error creating task", http.StatusInternalServerError)
 		return
 	}
+	if err := storeDescriptionHTML(tx, "tasks", task.ID, task.Description); err != nil {
+		http.Error(w, "Error rendering task description", http.StatusInternalServerError)
+		return
+	}
+	if err := trackCategory(tx, &task, time.Now().UTC()); err != nil {
+		http.Error(w, "Error updating task timestamps", http.StatusInternalServerError)
+		return
+	}
+
+	if err := saveCustomValues(tx, task.ID, customValues); err != nil {
+		http.Error(w, "Error saving custom fields", http.StatusInternalServerError)
+		return
+	}
+
+	if err := setTaskLabels(tx, task.ID, labels); err != nil {
+		http.Error(w, "Error saving task labels", http.StatusInternalServerError)
+		return
+	}
+
+	checklist := make([]string, 0, len(task.Checklist))
+	for _, item := range task.Checklist {
+		checklist = append(checklist, item.Content)
+	}
+	if err := addChecklistItems(tx, task.ID, checklist); err != nil {
+		http.Error(w, "Error saving task checklist", http.StatusInternalServerError)
+		return
+	}
+
+	for _, watcherID := range []*int{task.CreatedBy, task.AssigneeID} {
+		if watcherID == nil {
+			continue
+		}
+		if err := addWatcher(tx, task.ID, *watcherID); err != nil {
+			http.Error(w, "Error adding task watcher", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var changes []types.FieldChange
+	changes = recordChange(changes, "title", nil, task.Title)
+	changes = recordChange(changes, "description", nil, task.Description)
+	changes = recordChange(changes, "column_id", nil, task.ColumnID)
+	changes = recordChange(changes, "parent_id", nil, task.ParentID)
+	changes = recordChange(changes, "estimate_points", nil, task.EstimatePoints)
+	changes = recordChange(changes, "estimate_hours", nil, task.EstimateHours)
+	changes = recordChange(changes, "assignee_id", nil, task.AssigneeID)
+	if len(labels) > 0 {
+		changes = recordChange(changes, "labels", nil, labels)
+	}
+	for name, value := range task.CustomFields {
+		changes = recordChange(changes, customFieldPrefix+name, nil, value)
+	}
 
 	actionType := "create"
 	logMessage := "Task created successfully"
 
-	_, err = app.DB.Exec(
-		"INSERT INTO task_logs (task_id, action_type, log_message, created_at) VALUES ($1, $2, $3, $4)",
-		task.ID, actionType, logMessage, task.CreatedAt,
-	)
+	err = logTaskAction(tx, task.ID, actorID, actionType, logMessage, changes...)
 	if err != nil {
 		http.Error(w, "Error logging task creation", http.StatusInternalServerError)
 		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating task", http.StatusInternalServerError)
+		return
+	}
+
 	response := types.Task{
 		ID:          task.ID,
+		Key:         task.Key,
 		ColumnID:    task.ColumnID,
 		Title:       task.Title,
 		Description: task.Description,
 		CreatedAt:   task.CreatedAt,
+		ParentID:    task.ParentID,
+
+		EstimatePoints: task.EstimatePoints,
+		EstimateHours:  task.EstimateHours,
+		CreatedBy:      task.CreatedBy,
+		AssigneeID:     task.AssigneeID,
+	}
+
+	tasks := []types.Task{response}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+	response = tasks[0]
+	if warning != "" {
+		response.Warnings = []string{warning}
 	}
 
 	w.Header().Set("Content-type", "application/json")
@@ -78,15 +319,14 @@ func (app *App) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *App) GetTaskByID(w http.ResponseWriter, r *http.Request) {
-	taskID := chi.URLParam(r, "id")
-	if taskID == "" {
-		http.Error(w, "Task ID is required", http.StatusBadRequest)
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
 		return
 	}
 	var task types.Task
 
-	err := app.DB.QueryRow("SELECT id, column_id, title, description, created_at FROM tasks WHERE id = $1", taskID).
-		Scan(&task.ID, &task.ColumnID, &task.Title, &task.Description, &task.CreatedAt)
+	err = scanTask(app.DB.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND deleted_at IS NULL", taskID), &task)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Task not found", http.StatusNotFound)
@@ -96,27 +336,28 @@ func (app *App) GetTaskByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tasks := []types.Task{task}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+	tasks[0].Links, err = app.taskLinks(task.ID)
+	if err != nil {
+		http.Error(w, "Database error while fetching links", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(task)
+	json.NewEncoder(w).Encode(tasks[0])
 }
 
 func (app *App) GetTasks(w http.ResponseWriter, r *http.Request) {
-	rows, err := app.DB.Query("SELECT id, column_id, title, description, created_at FROM tasks")
+	tasks, err := app.listTasks(r, nil, nil)
 	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		respondError(w, err, "Database error")
 		return
 	}
-	defer rows.Close()
 
-	var tasks []types.Task
-	for rows.Next() {
-		var task types.Task
-		if err := rows.Scan(&task.ID, &task.ColumnID, &task.Title, &task.Description, &task.CreatedAt); err != nil {
-			http.Error(w, "Error scanning tasks", http.StatusInternalServerError)
-			return
-		}
-		tasks = append(tasks, task)
-	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(tasks)
 }
@@ -127,81 +368,181 @@ func (app *App) GetTasksByColumn(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Column ID is required", http.StatusBadRequest)
 		return
 	}
-	
-	rows, err := app.DB.Query("SELECT id, column_id, title, description, created_at FROM tasks WHERE column_id = $1", columnID)
+
+	tasks, err := app.listTasks(r, []string{"column_id = $1"}, []any{columnID})
 	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		respondError(w, err, "Database error")
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks)
+}
+
+func (app *App) listTasks(r *http.Request, conditions []string, args []any) ([]types.Task, error) {
+	conditions = append([]string{"deleted_at IS NULL"}, conditions...)
+	if r.URL.Query().Get("include_archived") != "true" {
+		conditions = append(conditions, "archived_at IS NULL", `column_id NOT IN (SELECT columns.id FROM columns
+			JOIN boards ON boards.id = columns.board_id WHERE boards.archived_at IS NOT NULL)`)
+	}
+	conditions, args = customFieldFilters(r, conditions, args)
+
+	category := r.URL.Query().Get("category")
+	if category == "" && strings.TrimPrefix(r.URL.Query().Get("sort"), "-") == "votes" {
+		// The most voted listing is meant for prioritizing the backlog.
+		category = CategoryBacklog
+	}
+	if category != "" {
+		if !isValidCategory(category) {
+			return nil, &apiError{http.StatusBadRequest, "category must be one of: backlog, todo, in-progress, done"}
+		}
+		args = append(args, category)
+		conditions = append(conditions, fmt.Sprintf("column_id IN (SELECT id FROM columns WHERE category = $%d)", len(args)))
+	}
+
+	query := "SELECT " + taskColumns + " FROM tasks WHERE " + strings.Join(conditions, " AND ")
+
+	orderBy, args, err := taskOrder(r.URL.Query().Get("sort"), args)
+	if err != nil {
+		return nil, err
+	}
+	query += " ORDER BY " + orderBy
+
+	rows, err := app.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var tasks []types.Task
 	for rows.Next() {
 		var task types.Task
-		if err := rows.Scan(&task.ID, &task.ColumnID, &task.Title, &task.Description, &task.CreatedAt); err != nil {
-			http.Error(w, "Error scanning tasks", http.StatusInternalServerError)
-			return
+		if err := scanTask(rows, &task); err != nil {
+			return nil, err
 		}
 		tasks = append(tasks, task)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(tasks)
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := app.enrichTasks(tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
 }
 
-func (app *App) GetTaskLogs(w http.ResponseWriter, r *http.Request) {
-	taskID := chi.URLParam(r, "id")
-	if taskID == "" {
-		http.Error(w, "Task ID is required", http.StatusBadRequest)
-		return
+func taskOrder(sort string, args []any) (string, []any, error) {
+	direction := "ASC"
+	if trimmed, ok := strings.CutPrefix(sort, "-"); ok {
+		direction = "DESC"
+		sort = trimmed
 	}
-	var logs types.TaskLog
 
-	err := app.DB.QueryRow("SELECT id, task_id, action_type, log_message, created_at FROM task_logs WHERE task_id = $1", taskID).
-		Scan(&logs.ID, &logs.TaskID, &logs.ActionType, &logs.LogMessage, &logs.CreatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "Task logs not found", http.StatusNotFound)
-			return
+	var expression string
+	switch sort {
+	case "", "id":
+		expression = "id"
+	case "title", "created_at", "position", "started_at", "completed_at":
+		expression = sort
+	case "votes":
+		expression = "(SELECT COUNT(*) FROM task_votes WHERE task_votes.task_id = tasks.id)"
+	default:
+		name, ok := strings.CutPrefix(sort, customFieldPrefix)
+		if !ok || name == "" {
+			return "", args, &apiError{http.StatusBadRequest, "Unsupported sort field"}
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+		expression, args = customFieldOrder(name, args)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(logs)
+	return fmt.Sprintf("%s %s NULLS LAST, id", expression, direction), args, nil
 }
 
-func (app *App) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
-	taskID := chi.URLParam(r, "id")
-	if taskID == "" {
-		http.Error(w, "Task ID is required", http.StatusBadRequest)
+func (app *App) GetTaskLogs(w http.ResponseWriter, r *http.Request) {
+	taskID, err := app.resolveTaskID(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, err, "Database error")
 		return
 	}
 
-	result, err := app.DB.Exec("DELETE FROM tasks WHERE id = $1", taskID)
+	page, err := parsePage(r)
 	if err != nil {
-		http.Error(w, "Database error while deleting task", http.StatusInternalServerError)
+		respondError(w, err, "Invalid pagination")
 		return
 	}
+	page.Logs = []types.TaskLog{}
 
-	rowsAffected, err := result.RowsAffected()
+	err = app.DB.QueryRow("SELECT COUNT(*) FROM task_logs WHERE task_id = $1", taskID).Scan(&page.Total)
 	if err != nil {
-		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
+		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	if rowsAffected == 0 {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	rows, err := app.DB.Query(`SELECT id, task_id, actor_id, action_type, log_message, changes, created_at FROM task_logs
+		WHERE task_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`, taskID, page.Limit, page.Offset)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	defer rows.Close()
 
-	actionType := "delete"
-	logMessage := "Task deleted successfully"
-	_, err = app.DB.Exec("INSERT INTO task_logs (task_id, action_type, log_message, created_at) VALUES ($1, $2, $3, $4)",
-		taskID, actionType, logMessage, time.Now(),
-	)
+	for rows.Next() {
+		var entry types.TaskLog
+		var changes []byte
+		if err := rows.Scan(&entry.ID, &entry.TaskID, &entry.ActorID, &entry.ActionType, &entry.LogMessage, &changes, &entry.CreatedAt); err != nil {
+			http.Error(w, "Error scanning task logs", http.StatusInternalServerError)
+			return
+		}
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			http.Error(w, "Error decoding task log changes", http.StatusInternalServerError)
+			return
+		}
+		page.Logs = append(page.Logs, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+func parsePage(r *http.Request) (types.TaskLogPage, error) {
+	page := types.TaskLogPage{Limit: defaultPageLimit}
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, &apiError{http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPageLimit)}
+		}
+		page.Limit = limit
+	}
+	if value := r.URL.Query().Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return page, &apiError{http.StatusBadRequest, "offset must be a non-negative number"}
+		}
+		page.Offset = offset
+	}
+	return page, nil
+}
+
+func (app *App) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		http.Error(w, "Error logging task deletion", http.StatusInternalServerError)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	err = app.moveToTrash(r, "task", taskID)
+	if err != nil {
+		if apiErr, ok := err.(*apiError); ok && apiErr.Status == http.StatusNotFound {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while deleting task", http.StatusInternalServerError)
 		return
 	}
 
@@ -224,8 +565,7 @@ func (app *App) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var existingTask types.Task
-	err = app.DB.QueryRow("SELECT id, column_id, title, description, created_at FROM tasks WHERE id = $1",
-		taskID).Scan(&existingTask.ID, &existingTask.ColumnID, &existingTask.Title, &existingTask.Description, &existingTask.CreatedAt)
+	err = scanTask(app.DB.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND deleted_at IS NULL", taskID), &existingTask)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Project not found", http.StatusNotFound)
@@ -235,34 +575,336 @@ func (app *App) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if updateData.Title != "" {
-		_, err = app.DB.Exec("UPDATE tasks SET title = $1 WHERE id = $2", updateData.Title, taskID)
+	tasks := []types.Task{existingTask}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+	before := tasks[0]
+
+	if !validEstimates(updateData) {
+		http.Error(w, "Estimates cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	projectID, err := app.columnProjectID(existingTask.ColumnID)
+	if err != nil {
+		http.Error(w, "Database error while fetching project", http.StatusInternalServerError)
+		return
+	}
+
+	assigneeID := existingTask.AssigneeID
+	if updateData.AssigneeID != nil {
+		assigneeID = updateData.AssigneeID
+		if *assigneeID == 0 {
+			assigneeID = nil
+		} else if err := app.checkProjectMember(projectID, *assigneeID); err != nil {
+			respondError(w, err, "Database error while checking assignee")
+			return
+		}
+	}
+
+	var customValues map[int][]byte
+	if updateData.CustomFields != nil {
+		customValues, err = validateCustomValues(app.DB, projectID, updateData.CustomFields, false)
 		if err != nil {
-			http.Error(w, "Error updating task title", http.StatusInternalServerError)
+			respondError(w, err, "Database error while checking custom fields")
 			return
 		}
-		existingTask.Title = updateData.Title
 	}
 
-	if updateData.Description != "" {
-		_, err = app.DB.Exec("UPDATE tasks SET description = $1 WHERE id = $2", updateData.Description, taskID)
+	var labels []string
+	if updateData.Labels != nil {
+		labels, err = normalizeLabels(updateData.Labels)
 		if err != nil {
-			http.Error(w, "Error updating task description", http.StatusInternalServerError)
+			respondError(w, err, "Invalid labels")
 			return
 		}
+	}
+
+	if updateData.Title != "" {
+		existingTask.Title = updateData.Title
+	}
+	if updateData.Description != "" {
 		existingTask.Description = updateData.Description
 	}
+	if updateData.EstimatePoints != nil {
+		existingTask.EstimatePoints = updateData.EstimatePoints
+	}
+	if updateData.EstimateHours != nil {
+		existingTask.EstimateHours = updateData.EstimateHours
+	}
+	existingTask.AssigneeID = assigneeID
+
+	var changes []types.FieldChange
+	changes = recordChange(changes, "title", before.Title, existingTask.Title)
+	changes = recordChange(changes, "description", before.Description, existingTask.Description)
+	changes = recordChange(changes, "estimate_points", before.EstimatePoints, existingTask.EstimatePoints)
+	changes = recordChange(changes, "estimate_hours", before.EstimateHours, existingTask.EstimateHours)
+	changes = recordChange(changes, "assignee_id", before.AssigneeID, existingTask.AssigneeID)
+	for name, value := range updateData.CustomFields {
+		changes = recordChange(changes, customFieldPrefix+name, before.CustomFields[name], value)
+	}
+	if updateData.Labels != nil {
+		changes = recordChange(changes, "labels", append([]string{}, before.Labels...), labels)
+	}
 
-	actionType := "update"
-	logMessage := "Task updated successfully"
-	_, err = app.DB.Exec("INSERT INTO task_logs (task_id, action_type, log_message, created_at) VALUES ($1, $2, $3, $4)",
-		taskID, actionType, logMessage, time.Now(),
-	)
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE tasks SET title = $1, description = $2, estimate_points = $3, estimate_hours = $4, assignee_id = $5
+		WHERE id = $6`,
+		existingTask.Title, existingTask.Description, existingTask.EstimatePoints, existingTask.EstimateHours,
+		existingTask.AssigneeID, existingTask.ID)
+	if err != nil {
+		http.Error(w, "Error updating task", http.StatusInternalServerError)
+		return
+	}
+	if err := storeDescriptionHTML(tx, "tasks", existingTask.ID, existingTask.Description); err != nil {
+		http.Error(w, "Error rendering task description", http.StatusInternalServerError)
+		return
+	}
+
+	if assigneeID != nil {
+		if err := addWatcher(tx, existingTask.ID, *assigneeID); err != nil {
+			http.Error(w, "Error adding task watcher", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := saveCustomValues(tx, existingTask.ID, customValues); err != nil {
+		http.Error(w, "Error saving custom fields", http.StatusInternalServerError)
+		return
+	}
+
+	if updateData.Labels != nil {
+		if err := setTaskLabels(tx, existingTask.ID, labels); err != nil {
+			http.Error(w, "Error saving task labels", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if len(changes) > 0 {
+		actionType := "update"
+		logMessage := "Task updated successfully"
+		err = logTaskAction(tx, existingTask.ID, app.optionalUserID(r), actionType, logMessage, changes...)
+		if err != nil {
+			http.Error(w, "Error logging task updation", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating task", http.StatusInternalServerError)
+		return
+	}
+
+	tasks = []types.Task{existingTask}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks[0])
+}
+
+// moveToProject renumbers an enriched task moving to another project and drops
+// what only applies to its old project: parent and children links, custom
+// values the target's fields do not accept and an assignee who is not a member
+// there. The target project's required fields must still be satisfied.
+func moveToProject(db querier, task *types.Task, projectID, actorID int) ([]types.FieldChange, error) {
+	// Task keys are per project, so a task moving to another project gets a new number there.
+	number, key, err := nextTaskNumber(db, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec("UPDATE tasks SET number = $1 WHERE id = $2", number, task.ID); err != nil {
+		return nil, err
+	}
+	changes := recordChange(nil, "key", task.Key, key)
+	task.Key = key
+
+	detached, err := detachHierarchy(db, *task, actorID)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, detached...)
+	task.ParentID = nil
+
+	carried, err := carryCustomValues(db, *task, projectID)
+	if err != nil {
+		return nil, err
+	}
+	customValues, err := validateCustomValues(db, projectID, carried, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec("DELETE FROM task_custom_values WHERE task_id = $1", task.ID); err != nil {
+		return nil, err
+	}
+	if err := saveCustomValues(db, task.ID, customValues); err != nil {
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(task.CustomFields)) {
+		changes = recordChange(changes, customFieldPrefix+name, task.CustomFields[name], carried[name])
+	}
+	task.CustomFields = carried
+
+	if task.AssigneeID != nil {
+		var isMember bool
+		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM project_users WHERE project_id = $1 AND user_id = $2)",
+			projectID, *task.AssigneeID).Scan(&isMember)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			if _, err := db.Exec("UPDATE tasks SET assignee_id = NULL WHERE id = $1", task.ID); err != nil {
+				return nil, err
+			}
+			changes = recordChange(changes, "assignee_id", task.AssigneeID, nil)
+			task.AssigneeID = nil
+		}
+	}
+	return changes, nil
+}
+
+func (app *App) MoveTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	if taskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var moveData struct {
+		ColumnID int `json:"column_id"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&moveData)
+	if err != nil || moveData.ColumnID == 0 {
+		http.Error(w, "Invalid request payload or missing column_id", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// The row stays locked until the move commits, so the workflow and WIP
+	// checks below see the column the task is actually leaving.
+	var task types.Task
+	err = scanTask(tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", taskID), &task)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching task", http.StatusInternalServerError)
+		return
+	}
+
+	var targetStatus, targetCategory string
+	err = app.DB.QueryRow("SELECT status, category FROM columns WHERE id = $1 AND deleted_at IS NULL", moveData.ColumnID).
+		Scan(&targetStatus, &targetCategory)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Column does not exist", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while checking column", http.StatusInternalServerError)
+		return
+	}
+
+	tasks := []types.Task{task}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+	task = tasks[0]
+
+	if app.EnforceBlockers && targetCategory == CategoryDone && hasUnresolvedBlockers(task) {
+		http.Error(w, "Task has unresolved blockers", http.StatusConflict)
+		return
+	}
+
+	sourceProjectID, err := app.columnProjectID(task.ColumnID)
 	if err != nil {
-		http.Error(w, "Error logging task updation", http.StatusInternalServerError)
+		http.Error(w, "Database error while checking column", http.StatusInternalServerError)
+		return
+	}
+	targetProjectID, err := app.columnProjectID(moveData.ColumnID)
+	if err != nil {
+		http.Error(w, "Database error while checking column", http.StatusInternalServerError)
+		return
+	}
+	if sourceProjectID != targetProjectID {
+		actorID, err := app.userIDFromRequest(r)
+		if err != nil {
+			respondError(w, err, "Database error while authenticating user")
+			return
+		}
+		if err := app.checkProjectMember(targetProjectID, actorID); err != nil {
+			respondError(w, err, "Database error while checking project membership")
+			return
+		}
+	}
+
+	if task.ColumnID != moveData.ColumnID {
+		if err := checkTransition(tx, task, moveData.ColumnID, app.optionalUserID(r)); err != nil {
+			respondError(w, err, "Database error while checking workflow")
+			return
+		}
+		warning, err := admitToColumn(tx, moveData.ColumnID)
+		if err != nil {
+			respondError(w, err, "Database error while checking WIP limit")
+			return
+		}
+		if warning != "" {
+			task.Warnings = []string{warning}
+		}
+	}
+
+	changes := recordChange(nil, "column_id", task.ColumnID, moveData.ColumnID)
+	if sourceProjectID != targetProjectID {
+		moved, err := moveToProject(tx, &task, targetProjectID, app.optionalUserID(r))
+		if err != nil {
+			respondError(w, err, "Error moving task to another project")
+			return
+		}
+		changes = append(changes, moved...)
+	}
+	err = tx.QueryRow("UPDATE tasks SET column_id = $1, "+movedPlacement+" WHERE id = $2 RETURNING swimlane_id",
+		moveData.ColumnID, task.ID).Scan(&task.SwimlaneID)
+	if err != nil {
+		http.Error(w, "Error moving task", http.StatusInternalServerError)
+		return
+	}
+	task.ColumnID = moveData.ColumnID
+	task.Position = nil
+	if err := trackCategory(tx, &task, time.Now().UTC()); err != nil {
+		http.Error(w, "Error updating task timestamps", http.StatusInternalServerError)
+		return
+	}
+
+	actionType := "move"
+	logMessage := "Task moved to column " + targetStatus
+	err = logTaskAction(tx, task.ID, app.optionalUserID(r), actionType, logMessage, changes...)
+	if err != nil {
+		http.Error(w, "Error logging task move", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error moving task", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(existingTask)
+	json.NewEncoder(w).Encode(task)
 }
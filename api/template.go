@@ -0,0 +1,557 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+const templateColumns = "id, project_id, column_id, name, title, description, labels, checklist, rrule, starts_at, next_run_at, occurrences, created_at"
+
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+func scanTemplate(row scanner, template *types.TaskTemplate) error {
+	err := row.Scan(&template.ID, &template.ProjectID, &template.ColumnID, &template.Name, &template.Title,
+		&template.Description, pq.Array(&template.Labels), pq.Array(&template.Checklist), &template.RRule,
+		&template.StartsAt, &template.NextRunAt, &template.Occurrences, &template.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if template.StartsAt != nil {
+		startsAt := template.StartsAt.UTC()
+		template.StartsAt = &startsAt
+	}
+	if template.NextRunAt != nil {
+		nextRunAt := template.NextRunAt.UTC()
+		template.NextRunAt = &nextRunAt
+	}
+	return nil
+}
+
+// scheduleTemplate validates the template's recurrence rule and computes its
+// next run, starting no earlier than now.
+func (app *App) scheduleTemplate(template *types.TaskTemplate) error {
+	template.NextRunAt = nil
+	if template.RRule == "" {
+		template.StartsAt = nil
+		return nil
+	}
+
+	rule, err := parseRRule(template.RRule)
+	if err != nil {
+		return &apiError{http.StatusBadRequest, "Invalid recurrence rule: " + err.Error()}
+	}
+	if template.ColumnID == nil {
+		return &apiError{http.StatusBadRequest, "Recurring templates require a column_id"}
+	}
+	// Scheduled runs have no caller to supply variables, so only built-in ones can be used.
+	if missing := templateMissingVariables(*template, time.Now().UTC(), nil); len(missing) > 0 {
+		return &apiError{http.StatusBadRequest, "Recurring templates can only use built-in variables, not: " + strings.Join(missing, ", ")}
+	}
+
+	if template.StartsAt == nil {
+		now := time.Now().UTC().Truncate(time.Minute)
+		template.StartsAt = &now
+	}
+	startsAt := template.StartsAt.UTC()
+	template.StartsAt = &startsAt
+
+	after := startsAt.Add(-time.Second)
+	if now := time.Now().UTC(); now.After(after) {
+		after = now
+	}
+	if nextRunAt, ok := rule.next(startsAt, after, template.Occurrences); ok {
+		template.NextRunAt = &nextRunAt
+	}
+	return nil
+}
+
+// renderTemplate substitutes {{name}} placeholders. Built-in variables
+// (date, weekday, week, month, year) describe the creation time and can be
+// overridden by the supplied ones. Unknown placeholders are left as they are
+// and reported back.
+func renderTemplate(text string, at time.Time, variables map[string]string) (string, []string) {
+	year, week := at.ISOWeek()
+	values := map[string]string{
+		"date":    at.Format(dateLayout),
+		"weekday": at.Weekday().String(),
+		"week":    strconv.Itoa(week),
+		"month":   at.Format("January"),
+		"year":    strconv.Itoa(year),
+	}
+	for name, value := range variables {
+		values[name] = value
+	}
+
+	var missing []string
+	rendered := templateVariable.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := templateVariable.FindStringSubmatch(placeholder)[1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			return placeholder
+		}
+		return value
+	})
+	return rendered, missing
+}
+
+func normalizeTemplate(template *types.TaskTemplate) error {
+	labels, err := normalizeLabels(template.Labels)
+	if err != nil {
+		return err
+	}
+	template.Labels = labels
+
+	checklist := make([]string, 0, len(template.Checklist))
+	for _, item := range template.Checklist {
+		if item = strings.TrimSpace(item); item != "" {
+			checklist = append(checklist, item)
+		}
+	}
+	template.Checklist = checklist
+	return nil
+}
+
+func (app *App) checkTemplateColumn(projectID int, columnID *int) error {
+	if columnID == nil {
+		return nil
+	}
+
+	columnProjectID, err := app.columnProjectID(*columnID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &apiError{http.StatusNotFound, "Column does not exist"}
+		}
+		return err
+	}
+	if columnProjectID != projectID {
+		return &apiError{http.StatusBadRequest, "Column must belong to the template's project"}
+	}
+	return nil
+}
+
+func (app *App) CreateTaskTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	var template types.TaskTemplate
+	err = json.NewDecoder(r.Body).Decode(&template)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if template.Name == "" || template.Title == "" {
+		http.Error(w, "Template name and title are required", http.StatusBadRequest)
+		return
+	}
+
+	var projectExists bool
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1 AND deleted_at IS NULL)", projectID).Scan(&projectExists)
+	if err != nil {
+		http.Error(w, "Database error while checking project", http.StatusInternalServerError)
+		return
+	}
+	if !projectExists {
+		http.Error(w, "Project does not exist", http.StatusNotFound)
+		return
+	}
+
+	if err := app.checkTemplateColumn(projectID, template.ColumnID); err != nil {
+		respondError(w, err, "Database error while checking column")
+		return
+	}
+
+	if err := normalizeTemplate(&template); err != nil {
+		respondError(w, err, "Invalid template")
+		return
+	}
+
+	template.Occurrences = 0
+	if err := app.scheduleTemplate(&template); err != nil {
+		respondError(w, err, "Error scheduling template")
+		return
+	}
+
+	err = scanTemplate(app.DB.QueryRow(`INSERT INTO task_templates
+		(project_id, column_id, name, title, description, labels, checklist, rrule, starts_at, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING `+templateColumns,
+		projectID, template.ColumnID, template.Name, template.Title, template.Description,
+		pq.Array(template.Labels), pq.Array(template.Checklist), template.RRule, template.StartsAt, template.NextRunAt), &template)
+	if err != nil {
+		http.Error(w, "Error creating task template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+func (app *App) GetTaskTemplates(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if projectID == "" {
+		http.Error(w, "Project ID is required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := app.DB.Query("SELECT "+templateColumns+" FROM task_templates WHERE project_id = $1 ORDER BY id", projectID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var templates []types.TaskTemplate
+	for rows.Next() {
+		var template types.TaskTemplate
+		if err := scanTemplate(rows, &template); err != nil {
+			http.Error(w, "Error scanning task templates", http.StatusInternalServerError)
+			return
+		}
+		templates = append(templates, template)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+func (app *App) GetTaskTemplateByID(w http.ResponseWriter, r *http.Request) {
+	templateID := chi.URLParam(r, "id")
+	var template types.TaskTemplate
+
+	err := scanTemplate(app.DB.QueryRow("SELECT "+templateColumns+" FROM task_templates WHERE id = $1", templateID), &template)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task template not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+func (app *App) UpdateTaskTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	templateID := chi.URLParam(r, "id")
+	if templateID == "" {
+		http.Error(w, "Template ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var updateData types.TaskTemplate
+	err := json.NewDecoder(r.Body).Decode(&updateData)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	var template types.TaskTemplate
+	err = scanTemplate(app.DB.QueryRow("SELECT "+templateColumns+" FROM task_templates WHERE id = $1", templateID), &template)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task template not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching task template", http.StatusInternalServerError)
+		return
+	}
+
+	if updateData.Name != "" {
+		template.Name = updateData.Name
+	}
+	if updateData.Title != "" {
+		template.Title = updateData.Title
+	}
+	if updateData.Description != "" {
+		template.Description = updateData.Description
+	}
+	if updateData.ColumnID != nil {
+		if err := app.checkTemplateColumn(template.ProjectID, updateData.ColumnID); err != nil {
+			respondError(w, err, "Database error while checking column")
+			return
+		}
+		template.ColumnID = updateData.ColumnID
+	}
+	if updateData.Labels != nil {
+		template.Labels = updateData.Labels
+	}
+	if updateData.Checklist != nil {
+		template.Checklist = updateData.Checklist
+	}
+	if err := normalizeTemplate(&template); err != nil {
+		respondError(w, err, "Invalid template")
+		return
+	}
+	if updateData.RRule != "" || updateData.StartsAt != nil {
+		template.Occurrences = 0
+	}
+	if updateData.RRule != "" {
+		template.RRule = updateData.RRule
+	}
+	if updateData.StartsAt != nil {
+		template.StartsAt = updateData.StartsAt
+	}
+
+	if err := app.scheduleTemplate(&template); err != nil {
+		respondError(w, err, "Error scheduling template")
+		return
+	}
+
+	_, err = app.DB.Exec(`UPDATE task_templates SET column_id = $1, name = $2, title = $3, description = $4,
+		labels = $5, checklist = $6, rrule = $7, starts_at = $8, next_run_at = $9, occurrences = $10 WHERE id = $11`,
+		template.ColumnID, template.Name, template.Title, template.Description,
+		pq.Array(template.Labels), pq.Array(template.Checklist),
+		template.RRule, template.StartsAt, template.NextRunAt, template.Occurrences, template.ID)
+	if err != nil {
+		http.Error(w, "Error updating task template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+func (app *App) StopTaskTemplateRecurrenceHandler(w http.ResponseWriter, r *http.Request) {
+	templateID := chi.URLParam(r, "id")
+	if templateID == "" {
+		http.Error(w, "Template ID is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.DB.Exec("UPDATE task_templates SET rrule = '', starts_at = NULL, next_run_at = NULL WHERE id = $1", templateID)
+	if err != nil {
+		http.Error(w, "Database error while updating task template", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Task template not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Task template recurrence stopped"))
+}
+
+func (app *App) DeleteTaskTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	templateID := chi.URLParam(r, "id")
+	if templateID == "" {
+		http.Error(w, "Template ID is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.DB.Exec("DELETE FROM task_templates WHERE id = $1", templateID)
+	if err != nil {
+		http.Error(w, "Database error while deleting task template", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Task template not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Task template deleted successfully"))
+}
+
+func (app *App) CreateTaskFromTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	templateID := chi.URLParam(r, "id")
+	if templateID == "" {
+		http.Error(w, "Template ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var instance types.TemplateInstance
+	if r.ContentLength != 0 {
+		err := json.NewDecoder(r.Body).Decode(&instance)
+		if err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var template types.TaskTemplate
+	err := scanTemplate(app.DB.QueryRow("SELECT "+templateColumns+" FROM task_templates WHERE id = $1", templateID), &template)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task template not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching task template", http.StatusInternalServerError)
+		return
+	}
+
+	columnID := template.ColumnID
+	if instance.ColumnID != nil {
+		if err := app.checkTemplateColumn(template.ProjectID, instance.ColumnID); err != nil {
+			respondError(w, err, "Database error while checking column")
+			return
+		}
+		columnID = instance.ColumnID
+	}
+	if columnID == nil {
+		http.Error(w, "ColumnID is required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if missing := templateMissingVariables(template, now, instance.Variables); len(missing) > 0 {
+		http.Error(w, "Missing template variables: "+strings.Join(missing, ", "), http.StatusBadRequest)
+		return
+	}
+	title, _ := renderTemplate(template.Title, now, instance.Variables)
+	if len([]rune(title)) > maxNameLength {
+		http.Error(w, "Rendered title is too long", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := checkEntry(tx, *columnID); err != nil {
+		respondError(w, err, "Database error while checking workflow")
+		return
+	}
+	warning, err := admitToColumn(tx, *columnID)
+	if err != nil {
+		respondError(w, err, "Database error while checking WIP limit")
+		return
+	}
+
+	task, err := instantiateTemplate(tx, template, *columnID, app.optionalUserID(r), now, instance.Variables)
+	if err != nil {
+		respondError(w, err, "Error creating task from template")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating task from template", http.StatusInternalServerError)
+		return
+	}
+
+	tasks := []types.Task{task}
+	if err := app.enrichTasks(tasks); err != nil {
+		http.Error(w, "Database error while fetching task details", http.StatusInternalServerError)
+		return
+	}
+	if warning != "" {
+		tasks[0].Warnings = []string{warning}
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(tasks[0])
+}
+
+// templateMissingVariables lists the placeholders of the template's title,
+// description and checklist that neither built-in nor supplied variables fill.
+func templateMissingVariables(template types.TaskTemplate, at time.Time, variables map[string]string) []string {
+	var missing []string
+	for _, text := range append([]string{template.Title, template.Description}, template.Checklist...) {
+		_, names := renderTemplate(text, at, variables)
+		for _, name := range names {
+			if !slices.Contains(missing, name) {
+				missing = append(missing, name)
+			}
+		}
+	}
+	return missing
+}
+
+// instantiateTemplate creates a task from the template in the given column,
+// rendering its title, description and checklist for the given time.
+func instantiateTemplate(db querier, template types.TaskTemplate, columnID, actorID int, at time.Time, variables map[string]string) (types.Task, error) {
+	if missing := templateMissingVariables(template, at, variables); len(missing) > 0 {
+		return types.Task{}, &apiError{http.StatusBadRequest, "Missing template variables: " + strings.Join(missing, ", ")}
+	}
+	title, _ := renderTemplate(template.Title, at, variables)
+	description, _ := renderTemplate(template.Description, at, variables)
+	if len([]rune(title)) > maxNameLength {
+		return types.Task{}, &apiError{http.StatusBadRequest, "Rendered title is too long"}
+	}
+	task := types.Task{
+		ColumnID:    columnID,
+		Title:       title,
+		Description: description,
+	}
+	if actorID != 0 {
+		task.CreatedBy = &actorID
+	}
+
+	number, key, err := nextTaskNumber(db, template.ProjectID)
+	if err != nil {
+		return task, err
+	}
+	task.Key = key
+
+	err = db.QueryRow("INSERT INTO tasks (column_id, title, description, created_by, number) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		task.ColumnID, task.Title, task.Description, task.CreatedBy, number).Scan(&task.ID, &task.CreatedAt)
+	if err != nil {
+		return task, err
+	}
+	if err := storeDescriptionHTML(db, "tasks", task.ID, task.Description); err != nil {
+		return task, err
+	}
+	if err := trackCategory(db, &task, time.Now().UTC()); err != nil {
+		return task, err
+	}
+
+	if err := setTaskLabels(db, task.ID, template.Labels); err != nil {
+		return task, err
+	}
+
+	checklist := make([]string, len(template.Checklist))
+	for i, item := range template.Checklist {
+		checklist[i], _ = renderTemplate(item, at, variables)
+	}
+	if err := addChecklistItems(db, task.ID, checklist); err != nil {
+		return task, err
+	}
+
+	if actorID != 0 {
+		if err := addWatcher(db, task.ID, actorID); err != nil {
+			return task, err
+		}
+	}
+
+	var changes []types.FieldChange
+	changes = recordChange(changes, "title", nil, task.Title)
+	changes = recordChange(changes, "description", nil, task.Description)
+	changes = recordChange(changes, "column_id", nil, task.ColumnID)
+	if len(template.Labels) > 0 {
+		changes = recordChange(changes, "labels", nil, template.Labels)
+	}
+
+	return task, logTaskAction(db, task.ID, actorID, "create", "Task created from template "+template.Name, changes...)
+}
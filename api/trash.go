@@ -0,0 +1,372 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+type trashSubtree struct {
+	table     string
+	condition string
+}
+
+type trashKind struct {
+	table       string
+	parentTable string
+	parentKey   string
+	subtree     []trashSubtree
+}
+
+// Items deleted together share one deleted_at timestamp, which is how a
+// restore finds the rest of the subtree without touching items that were
+// trashed separately before.
+var trashKinds = map[string]trashKind{
+	"project": {
+		table: "projects",
+		subtree: []trashSubtree{
+			{"boards", "project_id = $1"},
+			{"columns", "board_id IN (SELECT id FROM boards WHERE project_id = $1)"},
+			{"tasks", `column_id IN (SELECT columns.id FROM columns
+				JOIN boards ON boards.id = columns.board_id WHERE boards.project_id = $1)`},
+		},
+	},
+	"board": {
+		table:       "boards",
+		parentTable: "projects",
+		parentKey:   "project_id",
+		subtree: []trashSubtree{
+			{"columns", "board_id = $1"},
+			{"tasks", "column_id IN (SELECT id FROM columns WHERE board_id = $1)"},
+		},
+	},
+	"column": {
+		table:       "columns",
+		parentTable: "boards",
+		parentKey:   "board_id",
+		subtree: []trashSubtree{
+			{"tasks", "column_id = $1"},
+		},
+	},
+	"task": {
+		table:       "tasks",
+		parentTable: "columns",
+		parentKey:   "column_id",
+	},
+}
+
+func softDelete(db querier, kind string, id, actorID int, deletedAt time.Time) error {
+	trash := trashKinds[kind]
+
+	var deletedBy *int
+	if actorID != 0 {
+		deletedBy = &actorID
+	}
+
+	result, err := db.Exec("UPDATE "+trash.table+" SET deleted_at = $2, deleted_by = $3 WHERE id = $1 AND deleted_at IS NULL",
+		id, deletedAt, deletedBy)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return &apiError{http.StatusNotFound, "Item not found"}
+	}
+
+	for _, subtree := range trash.subtree {
+		_, err := db.Exec("UPDATE "+subtree.table+" SET deleted_at = $2, deleted_by = $3 WHERE "+subtree.condition+" AND deleted_at IS NULL",
+			id, deletedAt, deletedBy)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRestoredNames keeps project and board names unique, as creating them
+// does, by rejecting a restore that would bring back a name in use. A project
+// brings back the boards deleted together with it.
+func checkRestoredNames(db querier, kind string, id int, deletedAt time.Time) error {
+	type nameCheck struct {
+		table     string
+		condition string
+		args      []any
+	}
+	var checks []nameCheck
+	switch kind {
+	case "project":
+		checks = []nameCheck{
+			{"projects", "restored.id = $1", []any{id}},
+			{"boards", "restored.project_id = $1 AND restored.deleted_at = $2", []any{id, deletedAt}},
+		}
+	case "board":
+		checks = []nameCheck{{"boards", "restored.id = $1", []any{id}}}
+	}
+
+	for _, check := range checks {
+		var name string
+		err := db.QueryRow("SELECT restored.name FROM "+check.table+" restored JOIN "+check.table+
+			" live ON live.name = restored.name AND live.deleted_at IS NULL WHERE "+check.condition+" LIMIT 1",
+			check.args...).Scan(&name)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return &apiError{http.StatusConflict, fmt.Sprintf("%q is already in use, rename it before restoring", name)}
+	}
+	return nil
+}
+
+func restoreDeleted(db querier, kind string, id int) (time.Time, error) {
+	trash := trashKinds[kind]
+
+	var deletedAt *time.Time
+	err := db.QueryRow("SELECT deleted_at FROM "+trash.table+" WHERE id = $1", id).Scan(&deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, &apiError{http.StatusNotFound, "Item not found"}
+		}
+		return time.Time{}, err
+	}
+	if deletedAt == nil {
+		return time.Time{}, &apiError{http.StatusConflict, "Item is not in the trash"}
+	}
+
+	if trash.parentTable != "" {
+		var parentDeleted bool
+		err := db.QueryRow("SELECT deleted_at IS NOT NULL FROM "+trash.parentTable+
+			" WHERE id = (SELECT "+trash.parentKey+" FROM "+trash.table+" WHERE id = $1)", id).Scan(&parentDeleted)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if parentDeleted {
+			return time.Time{}, &apiError{http.StatusConflict, "The containing item is in the trash, restore it first"}
+		}
+	}
+
+	if err := checkRestoredNames(db, kind, id, *deletedAt); err != nil {
+		return time.Time{}, err
+	}
+
+	_, err = db.Exec("UPDATE "+trash.table+" SET deleted_at = NULL, deleted_by = NULL WHERE id = $1", id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, subtree := range trash.subtree {
+		_, err := db.Exec("UPDATE "+subtree.table+" SET deleted_at = NULL, deleted_by = NULL WHERE "+subtree.condition+" AND deleted_at = $2",
+			id, *deletedAt)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return deletedAt.UTC(), nil
+}
+
+func (app *App) moveToTrash(r *http.Request, kind string, id int) error {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	actorID := app.optionalUserID(r)
+	deletedAt := time.Now().UTC().Truncate(time.Microsecond)
+	if err := softDelete(tx, kind, id, actorID, deletedAt); err != nil {
+		return err
+	}
+	if kind == "task" {
+		changes := recordChange(nil, "deleted_at", nil, deletedAt)
+		if err := logTaskAction(tx, id, actorID, "delete", "Task moved to trash", changes...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (app *App) restoreFromTrash(w http.ResponseWriter, r *http.Request, kind string) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// A restored task counts toward its column's load again. Columns, boards
+	// and projects come back together with their own tasks and are not admitted.
+	if kind == "task" {
+		var columnID int
+		err := tx.QueryRow("SELECT column_id FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL AND archived_at IS NULL",
+			id).Scan(&columnID)
+		if err != nil && err != sql.ErrNoRows {
+			http.Error(w, "Database error while fetching task", http.StatusInternalServerError)
+			return
+		}
+		if err == nil {
+			if _, err := admitToColumn(tx, columnID); err != nil {
+				respondError(w, err, "Database error while checking WIP limit")
+				return
+			}
+		}
+	}
+
+	deletedAt, err := restoreDeleted(tx, kind, id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			http.Error(w, "An item with the same name already exists", http.StatusConflict)
+			return
+		}
+		respondError(w, err, "Database error while restoring item")
+		return
+	}
+	if kind == "task" {
+		changes := recordChange(nil, "deleted_at", deletedAt, nil)
+		if err := logTaskAction(tx, id, app.optionalUserID(r), "restore", "Task restored from trash", changes...); err != nil {
+			http.Error(w, "Error logging task restore", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error while restoring item", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Restored successfully"))
+}
+
+func (app *App) RestoreProjectHandler(w http.ResponseWriter, r *http.Request) {
+	app.restoreFromTrash(w, r, "project")
+}
+
+func (app *App) RestoreBoardHandler(w http.ResponseWriter, r *http.Request) {
+	app.restoreFromTrash(w, r, "board")
+}
+
+func (app *App) RestoreColumnHandler(w http.ResponseWriter, r *http.Request) {
+	app.restoreFromTrash(w, r, "column")
+}
+
+func (app *App) RestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	app.restoreFromTrash(w, r, "task")
+}
+
+func (app *App) GetProjectTrash(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if projectID == "" {
+		http.Error(w, "Project ID is required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT 'board', boards.id, boards.name, boards.deleted_at, boards.deleted_by
+		FROM boards
+		JOIN projects ON projects.id = boards.project_id
+		WHERE boards.project_id = $1 AND boards.deleted_at IS NOT NULL
+			AND projects.deleted_at IS DISTINCT FROM boards.deleted_at
+		UNION ALL
+		SELECT 'column', columns.id, columns.status, columns.deleted_at, columns.deleted_by
+		FROM columns
+		JOIN boards ON boards.id = columns.board_id
+		WHERE boards.project_id = $1 AND columns.deleted_at IS NOT NULL
+			AND boards.deleted_at IS DISTINCT FROM columns.deleted_at
+		UNION ALL
+		SELECT 'task', tasks.id, tasks.title, tasks.deleted_at, tasks.deleted_by
+		FROM tasks
+		JOIN columns ON columns.id = tasks.column_id
+		JOIN boards ON boards.id = columns.board_id
+		WHERE boards.project_id = $1 AND tasks.deleted_at IS NOT NULL
+			AND columns.deleted_at IS DISTINCT FROM tasks.deleted_at
+		ORDER BY 4 DESC`, projectID)
+	if err != nil {
+		http.Error(w, "Database error while fetching trash", http.StatusInternalServerError)
+		return
+	}
+
+	app.writeTrashItems(w, rows)
+}
+
+func (app *App) GetProjectsTrash(w http.ResponseWriter, r *http.Request) {
+	rows, err := app.DB.Query(`SELECT 'project', id, name, deleted_at, deleted_by FROM projects
+		WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+	if err != nil {
+		http.Error(w, "Database error while fetching trash", http.StatusInternalServerError)
+		return
+	}
+
+	app.writeTrashItems(w, rows)
+}
+
+func (app *App) writeTrashItems(w http.ResponseWriter, rows *sql.Rows) {
+	defer rows.Close()
+
+	items := []types.TrashItem{}
+	for rows.Next() {
+		var item types.TrashItem
+		if err := rows.Scan(&item.Type, &item.ID, &item.Name, &item.DeletedAt, &item.DeletedBy); err != nil {
+			http.Error(w, "Error scanning trash", http.StatusInternalServerError)
+			return
+		}
+		item.DeletedAt = item.DeletedAt.UTC()
+		if app.TrashRetention > 0 {
+			purgeAt := item.DeletedAt.Add(app.TrashRetention)
+			item.PurgeAt = &purgeAt
+		}
+		items = append(items, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// StartTrashPurger permanently deletes trashed items once they have been in
+// the trash longer than the configured retention. A zero retention keeps them forever.
+func (app *App) StartTrashPurger(interval time.Duration) {
+	if app.TrashRetention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := app.purgeTrash(time.Now().UTC().Add(-app.TrashRetention)); err != nil {
+				log.Printf("Error purging trash: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func (app *App) purgeTrash(cutoff time.Time) error {
+	for _, table := range []string{"projects", "boards", "columns", "tasks"} {
+		result, err := app.DB.Exec("DELETE FROM "+table+" WHERE deleted_at < $1", cutoff)
+		if err != nil {
+			return err
+		}
+		if purged, err := result.RowsAffected(); err == nil && purged > 0 {
+			log.Printf("Purged %d %s from trash", purged, table)
+		}
+	}
+	return nil
+}
@@ -26,7 +26,7 @@ func (app *App) AddUserToProjectHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	var projectExists bool
-	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", projectID).Scan(&projectExists)
+	err = app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1 AND deleted_at IS NULL)", projectID).Scan(&projectExists)
 	if err != nil {
 		http.Error(w, "Database error while checking project", http.StatusInternalServerError)
 		return
@@ -156,3 +156,16 @@ func (app *App) GetProjectUsersHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(users)
 }
+
+func (app *App) checkProjectMember(projectID, userID int) error {
+	var isMember bool
+	err := app.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM project_users WHERE project_id = $1 AND user_id = $2)",
+		projectID, userID).Scan(&isMember)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return &apiError{http.StatusForbidden, "User is not a member of this project"}
+	}
+	return nil
+}
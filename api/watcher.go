@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"kanban-board/types"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func addWatcher(db querier, taskID, userID int) error {
+	_, err := db.Exec("INSERT INTO task_watchers (task_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		taskID, userID)
+	return err
+}
+
+func (app *App) WatchTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	projectID, err := app.taskProjectID(taskID)
+	if err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+	if err := app.checkProjectMember(projectID, userID); err != nil {
+		respondError(w, err, "Database error while checking project membership")
+		return
+	}
+
+	if err := addWatcher(app.DB, taskID, userID); err != nil {
+		http.Error(w, "Error watching task", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Task watched successfully"))
+}
+
+func (app *App) UnwatchTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	if taskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	result, err := app.DB.Exec("DELETE FROM task_watchers WHERE task_id = $1 AND user_id = $2", taskID, userID)
+	if err != nil {
+		http.Error(w, "Database error while unwatching task", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Error checking rows affected", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Task is not watched", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Task unwatched successfully"))
+}
+
+func (app *App) GetTaskWatchers(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	if taskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := app.DB.Query(`
+		SELECT users.id, users.username, users.email, task_watchers.created_at
+		FROM task_watchers
+		JOIN users ON users.id = task_watchers.user_id
+		WHERE task_watchers.task_id = $1
+		ORDER BY task_watchers.created_at`, taskID)
+	if err != nil {
+		http.Error(w, "Database error while fetching watchers", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var watchers []types.Watcher
+	for rows.Next() {
+		var watcher types.Watcher
+		if err := rows.Scan(&watcher.UserID, &watcher.Username, &watcher.Email, &watcher.CreatedAt); err != nil {
+			http.Error(w, "Error scanning watchers", http.StatusInternalServerError)
+			return
+		}
+		watchers = append(watchers, watcher)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(watchers)
+}
+
+func (app *App) GetWatchedTasks(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	tasks, err := app.listTasks(r, []string{"id IN (SELECT task_id FROM task_watchers WHERE user_id = $1)"}, []any{userID})
+	if err != nil {
+		respondError(w, err, "Database error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks)
+}
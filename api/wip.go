@@ -0,0 +1,148 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	WIPSoft = "soft"
+	WIPHard = "hard"
+
+	WIPStatusOK    = "ok"
+	WIPStatusOver  = "over"
+	WIPStatusUnder = "under"
+)
+
+// columnLoadExpr counts the active tasks of a column toward its WIP limits.
+const columnLoadExpr = `(SELECT COUNT(*) FROM tasks
+	WHERE tasks.column_id = columns.id AND tasks.deleted_at IS NULL AND tasks.archived_at IS NULL)`
+
+const columnSelect = "id, board_id, status, category, position, wip_min, wip_max, wip_mode, " + columnLoadExpr
+
+func scanColumn(row scanner, column *types.Column) error {
+	err := row.Scan(&column.ID, &column.BoardID, &column.Status, &column.Category, &column.Position,
+		&column.WIPMin, &column.WIPMax, &column.WIPMode, &column.Load)
+	if err != nil {
+		return err
+	}
+	column.WIPStatus = wipStatus(column.Load, column.WIPMin, column.WIPMax)
+	return nil
+}
+
+func wipStatus(load int, wipMin, wipMax *int) string {
+	switch {
+	case wipMin == nil && wipMax == nil:
+		return ""
+	case wipMax != nil && load > *wipMax:
+		return WIPStatusOver
+	case wipMin != nil && load < *wipMin:
+		return WIPStatusUnder
+	}
+	return WIPStatusOK
+}
+
+func validateWIPLimits(limits *types.WIPLimits) error {
+	if limits.WIPMode == "" {
+		limits.WIPMode = WIPSoft
+	}
+	if limits.WIPMode != WIPSoft && limits.WIPMode != WIPHard {
+		return &apiError{http.StatusBadRequest, "wip_mode must be one of: soft, hard"}
+	}
+	if limits.WIPMin != nil && *limits.WIPMin < 0 {
+		return &apiError{http.StatusBadRequest, "wip_min cannot be negative"}
+	}
+	if limits.WIPMax != nil && *limits.WIPMax < 1 {
+		return &apiError{http.StatusBadRequest, "wip_max must be at least 1"}
+	}
+	if limits.WIPMin != nil && limits.WIPMax != nil && *limits.WIPMin > *limits.WIPMax {
+		return &apiError{http.StatusBadRequest, "wip_min cannot be greater than wip_max"}
+	}
+	return nil
+}
+
+// admitToColumn checks that the column takes new tasks, which columns of
+// archived boards do not, and that one more task fits under its WIP max.
+// It locks the column row, so concurrent admissions to the same column are
+// counted one after another. A hard limit rejects the task with 409; a soft
+// limit lets it in and returns a warning. Minimum limits are only reported.
+func admitToColumn(db querier, columnID int) (string, error) {
+	var status, mode string
+	var wipMax *int
+	var boardArchived bool
+	err := db.QueryRow(`SELECT columns.status, columns.wip_max, columns.wip_mode, boards.archived_at IS NOT NULL
+		FROM columns JOIN boards ON boards.id = columns.board_id
+		WHERE columns.id = $1 FOR NO KEY UPDATE OF columns`, columnID).
+		Scan(&status, &wipMax, &mode, &boardArchived)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", &apiError{http.StatusNotFound, "Column does not exist"}
+		}
+		return "", err
+	}
+	if boardArchived {
+		return "", &apiError{http.StatusConflict, fmt.Sprintf("Column %q belongs to an archived board", status)}
+	}
+	if wipMax == nil {
+		return "", nil
+	}
+
+	var load int
+	err = db.QueryRow("SELECT COUNT(*) FROM tasks WHERE column_id = $1 AND deleted_at IS NULL AND archived_at IS NULL",
+		columnID).Scan(&load)
+	if err != nil {
+		return "", err
+	}
+	if load+1 <= *wipMax {
+		return "", nil
+	}
+
+	if mode == WIPHard {
+		return "", &apiError{http.StatusConflict, fmt.Sprintf("Column %q has reached its WIP limit of %d", status, *wipMax)}
+	}
+	return fmt.Sprintf("Column %q is over its WIP limit (%d/%d)", status, load+1, *wipMax), nil
+}
+
+func (app *App) UpdateColumnWIPHandler(w http.ResponseWriter, r *http.Request) {
+	columnID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid column ID", http.StatusBadRequest)
+		return
+	}
+
+	var limits types.WIPLimits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := validateWIPLimits(&limits); err != nil {
+		respondError(w, err, "Invalid WIP limits")
+		return
+	}
+
+	result, err := app.DB.Exec("UPDATE columns SET wip_min = $1, wip_max = $2, wip_mode = $3 WHERE id = $4 AND deleted_at IS NULL",
+		limits.WIPMin, limits.WIPMax, limits.WIPMode, columnID)
+	if err != nil {
+		http.Error(w, "Error updating WIP limits", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		http.Error(w, "Column not found", http.StatusNotFound)
+		return
+	}
+
+	var column types.Column
+	if err := scanColumn(app.DB.QueryRow("SELECT "+columnSelect+" FROM columns WHERE id = $1", columnID), &column); err != nil {
+		http.Error(w, "Database error while fetching column", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(column)
+}
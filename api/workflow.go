@@ -0,0 +1,302 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+// transitionFields are the task fields a transition can require besides
+// custom fields, which are named cf.<name>.
+var transitionFields = []string{"assignee", "description", "estimate_points", "estimate_hours", "labels"}
+
+func boardWorkflow(db querier, boardID int) (types.Workflow, error) {
+	workflow := types.Workflow{BoardID: boardID, Transitions: []types.WorkflowTransition{}}
+	rows, err := db.Query(`SELECT workflow_transitions.id, from_column_id, to_column_id, roles, required_fields
+		FROM workflow_transitions
+		JOIN columns source ON source.id = from_column_id
+		WHERE workflow_transitions.board_id = $1
+		ORDER BY source.position, from_column_id, to_column_id`, boardID)
+	if err != nil {
+		return workflow, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transition types.WorkflowTransition
+		err := rows.Scan(&transition.ID, &transition.FromColumnID, &transition.ToColumnID,
+			pq.Array(&transition.Roles), pq.Array(&transition.RequiredFields))
+		if err != nil {
+			return workflow, err
+		}
+		workflow.Transitions = append(workflow.Transitions, transition)
+	}
+	return workflow, rows.Err()
+}
+
+func normalizeNames(names []string) []string {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(normalized, name) {
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
+func validateTransitionFields(db querier, projectID int, fields []string) error {
+	for _, field := range fields {
+		if slices.Contains(transitionFields, field) {
+			continue
+		}
+		name, ok := strings.CutPrefix(field, customFieldPrefix)
+		if !ok || name == "" {
+			return &apiError{http.StatusBadRequest, fmt.Sprintf("Unknown required field %q", field)}
+		}
+		var exists bool
+		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM custom_fields WHERE project_id = $1 AND name = $2)",
+			projectID, name).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return &apiError{http.StatusBadRequest, fmt.Sprintf("Custom field %q does not exist", name)}
+		}
+	}
+	return nil
+}
+
+// missingFields lists the required fields an enriched task has no value for.
+func missingFields(task types.Task, fields []string) []string {
+	var missing []string
+	for _, field := range fields {
+		var set bool
+		switch field {
+		case "assignee":
+			set = task.AssigneeID != nil
+		case "description":
+			set = strings.TrimSpace(task.Description) != ""
+		case "estimate_points":
+			set = task.EstimatePoints != nil
+		case "estimate_hours":
+			set = task.EstimateHours != nil
+		case "labels":
+			set = len(task.Labels) > 0
+		default:
+			switch value := task.CustomFields[strings.TrimPrefix(field, customFieldPrefix)].(type) {
+			case nil:
+			case string:
+				set = value != ""
+			case []any:
+				set = len(value) > 0
+			case []string:
+				set = len(value) > 0
+			default:
+				set = true
+			}
+		}
+		if !set {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// checkEntry rejects a task placed straight into a column, by creating it
+// there or moving it in from another board, when the board's workflow only
+// lets tasks reach that column through its transitions.
+func checkEntry(db querier, columnID int) error {
+	var status string
+	var hasInbound bool
+	err := db.QueryRow(`SELECT status, EXISTS(SELECT 1 FROM workflow_transitions WHERE to_column_id = columns.id)
+		FROM columns WHERE id = $1`, columnID).Scan(&status, &hasInbound)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &apiError{http.StatusNotFound, "Column does not exist"}
+		}
+		return err
+	}
+	if hasInbound {
+		return &apiError{http.StatusConflict, fmt.Sprintf("The board's workflow only allows reaching %q through its transitions", status)}
+	}
+	return nil
+}
+
+// checkTransition enforces the workflow of the task's board on a move to
+// another column. Boards without transitions allow every move. A task coming
+// from another board is checked like a new one by checkEntry, so it cannot
+// skip steps by passing through a second board.
+func checkTransition(db querier, task types.Task, toColumnID, actorID int) error {
+	if task.ColumnID == toColumnID {
+		return nil
+	}
+
+	var fromBoardID, toBoardID int
+	var fromStatus, toStatus string
+	err := db.QueryRow(`SELECT source.board_id, source.status, target.board_id, target.status
+		FROM columns source, columns target WHERE source.id = $1 AND target.id = $2`, task.ColumnID, toColumnID).
+		Scan(&fromBoardID, &fromStatus, &toBoardID, &toStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &apiError{http.StatusNotFound, "Column does not exist"}
+		}
+		return err
+	}
+	if fromBoardID != toBoardID {
+		return checkEntry(db, toColumnID)
+	}
+
+	var hasWorkflow bool
+	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM workflow_transitions WHERE board_id = $1)", fromBoardID).Scan(&hasWorkflow)
+	if err != nil || !hasWorkflow {
+		return err
+	}
+
+	var roles, requiredFields []string
+	err = db.QueryRow("SELECT roles, required_fields FROM workflow_transitions WHERE from_column_id = $1 AND to_column_id = $2",
+		task.ColumnID, toColumnID).Scan(pq.Array(&roles), pq.Array(&requiredFields))
+	if err == sql.ErrNoRows {
+		return &apiError{http.StatusConflict, fmt.Sprintf("The board's workflow does not allow moving from %q to %q", fromStatus, toStatus)}
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(roles) > 0 {
+		var role string
+		err := db.QueryRow(`SELECT project_users.role FROM project_users
+			JOIN boards ON boards.project_id = project_users.project_id
+			WHERE boards.id = $1 AND project_users.user_id = $2`, fromBoardID, actorID).Scan(&role)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if !slices.Contains(roles, role) {
+			return &apiError{http.StatusForbidden, fmt.Sprintf("Moving to %q requires one of the roles: %s", toStatus, strings.Join(roles, ", "))}
+		}
+	}
+
+	if missing := missingFields(task, requiredFields); len(missing) > 0 {
+		return &apiError{http.StatusConflict, fmt.Sprintf("Moving to %q requires: %s", toStatus, strings.Join(missing, ", "))}
+	}
+	return nil
+}
+
+func (app *App) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+	if _, _, err := boardLaneSettings(app.DB, boardID); err != nil {
+		respondError(w, err, "Database error while fetching board")
+		return
+	}
+
+	workflow, err := boardWorkflow(app.DB, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching workflow", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// UpdateWorkflowHandler replaces the transitions of a board. An empty list
+// removes the workflow and lets tasks move freely again.
+func (app *App) UpdateWorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	boardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid board ID", http.StatusBadRequest)
+		return
+	}
+
+	var workflow types.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&workflow); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	projectID, _, err := boardLaneSettings(tx, boardID)
+	if err != nil {
+		respondError(w, err, "Database error while fetching board")
+		return
+	}
+	columns, err := boardColumns(tx, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching columns", http.StatusInternalServerError)
+		return
+	}
+	onBoard := make(map[int]bool, len(columns))
+	for _, column := range columns {
+		onBoard[column.ID] = true
+	}
+
+	seen := make(map[[2]int]bool, len(workflow.Transitions))
+	for i := range workflow.Transitions {
+		transition := &workflow.Transitions[i]
+		if !onBoard[transition.FromColumnID] || !onBoard[transition.ToColumnID] {
+			http.Error(w, "Transitions must be between columns of the board", http.StatusBadRequest)
+			return
+		}
+		if transition.FromColumnID == transition.ToColumnID {
+			http.Error(w, "A transition must lead to another column", http.StatusBadRequest)
+			return
+		}
+		pair := [2]int{transition.FromColumnID, transition.ToColumnID}
+		if seen[pair] {
+			http.Error(w, "Each transition can only be listed once", http.StatusBadRequest)
+			return
+		}
+		seen[pair] = true
+
+		transition.Roles = normalizeNames(transition.Roles)
+		transition.RequiredFields = normalizeNames(transition.RequiredFields)
+		if err := validateTransitionFields(tx, projectID, transition.RequiredFields); err != nil {
+			respondError(w, err, "Database error while checking required fields")
+			return
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM workflow_transitions WHERE board_id = $1", boardID); err != nil {
+		http.Error(w, "Error updating workflow", http.StatusInternalServerError)
+		return
+	}
+	for _, transition := range workflow.Transitions {
+		_, err := tx.Exec(`INSERT INTO workflow_transitions (board_id, from_column_id, to_column_id, roles, required_fields)
+			VALUES ($1, $2, $3, $4, $5)`, boardID, transition.FromColumnID, transition.ToColumnID,
+			pq.Array(transition.Roles), pq.Array(transition.RequiredFields))
+		if err != nil {
+			http.Error(w, "Error updating workflow", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	workflow, err = boardWorkflow(tx, boardID)
+	if err != nil {
+		http.Error(w, "Database error while fetching workflow", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating workflow", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
@@ -0,0 +1,378 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"kanban-board/types"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const dateLayout = "2006-01-02"
+
+const worklogColumns = "worklogs.id, worklogs.task_id, worklogs.user_id, worklogs.duration_minutes, worklogs.work_date, worklogs.note, worklogs.created_at"
+
+func scanWorklog(row scanner, worklog *types.Worklog, extra ...any) error {
+	var workDate time.Time
+	dest := []any{&worklog.ID, &worklog.TaskID, &worklog.UserID, &worklog.DurationMinutes, &workDate, &worklog.Note, &worklog.CreatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	worklog.WorkDate = workDate.Format(dateLayout)
+	return nil
+}
+
+func parseDateRange(r *http.Request) (time.Time, time.Time, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return from, to, &apiError{http.StatusBadRequest, "Invalid from date, expected YYYY-MM-DD"}
+		}
+		from = parsed
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return from, to, &apiError{http.StatusBadRequest, "Invalid to date, expected YYYY-MM-DD"}
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		return from, to, &apiError{http.StatusBadRequest, "The to date must not be before the from date"}
+	}
+
+	return from, to, nil
+}
+
+func (app *App) CreateWorklogHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	var worklog types.Worklog
+	err = json.NewDecoder(r.Body).Decode(&worklog)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if worklog.DurationMinutes <= 0 {
+		http.Error(w, "Duration must be positive", http.StatusBadRequest)
+		return
+	}
+
+	workDate := time.Now()
+	if worklog.WorkDate != "" {
+		workDate, err = time.Parse(dateLayout, worklog.WorkDate)
+		if err != nil {
+			http.Error(w, "Invalid work date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	projectID, err := app.taskProjectID(taskID)
+	if err != nil {
+		respondError(w, err, "Database error while checking task")
+		return
+	}
+	if err := app.checkProjectMember(projectID, userID); err != nil {
+		respondError(w, err, "Database error while checking project membership")
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = scanWorklog(tx.QueryRow(`INSERT INTO worklogs (task_id, user_id, duration_minutes, work_date, note)
+		VALUES ($1, $2, $3, $4, $5) RETURNING `+worklogColumns,
+		taskID, userID, worklog.DurationMinutes, workDate.Format(dateLayout), worklog.Note), &worklog)
+	if err != nil {
+		http.Error(w, "Error creating worklog", http.StatusInternalServerError)
+		return
+	}
+
+	changes := recordChange(nil, "worklog", nil, worklog)
+	err = logTaskAction(tx, taskID, userID, "worklog", fmt.Sprintf("Logged %d minutes", worklog.DurationMinutes), changes...)
+	if err != nil {
+		http.Error(w, "Error logging worklog creation", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating worklog", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(worklog)
+}
+
+func (app *App) GetTaskWorklogs(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	if taskID == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var tracking types.TimeTracking
+	err := app.DB.QueryRow("SELECT estimate_hours FROM tasks WHERE id = $1 AND deleted_at IS NULL", taskID).Scan(&tracking.EstimateHours)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := app.DB.Query("SELECT "+worklogColumns+" FROM worklogs WHERE task_id = $1 ORDER BY work_date, id", taskID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	loggedMinutes := 0
+	tracking.Worklogs = []types.Worklog{}
+	for rows.Next() {
+		var worklog types.Worklog
+		if err := scanWorklog(rows, &worklog); err != nil {
+			http.Error(w, "Error scanning worklogs", http.StatusInternalServerError)
+			return
+		}
+		loggedMinutes += worklog.DurationMinutes
+		tracking.Worklogs = append(tracking.Worklogs, worklog)
+	}
+
+	tracking.LoggedHours = float64(loggedMinutes) / 60
+	if tracking.EstimateHours != nil {
+		remaining := max(*tracking.EstimateHours-tracking.LoggedHours, 0)
+		tracking.RemainingHours = &remaining
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tracking)
+}
+
+func (app *App) DeleteWorklogHandler(w http.ResponseWriter, r *http.Request) {
+	worklogID := chi.URLParam(r, "id")
+	if worklogID == "" {
+		http.Error(w, "Worklog ID is required", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	var ownerID int
+	err = app.DB.QueryRow("SELECT user_id FROM worklogs WHERE id = $1", worklogID).Scan(&ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Worklog not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while fetching worklog", http.StatusInternalServerError)
+		return
+	}
+	if ownerID != userID {
+		http.Error(w, "Only the author can delete a worklog", http.StatusForbidden)
+		return
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var worklog types.Worklog
+	err = scanWorklog(tx.QueryRow("DELETE FROM worklogs WHERE id = $1 RETURNING "+worklogColumns, worklogID), &worklog)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Worklog not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error while deleting worklog", http.StatusInternalServerError)
+		return
+	}
+
+	changes := recordChange(nil, "worklog", worklog, nil)
+	err = logTaskAction(tx, worklog.TaskID, userID, "worklog",
+		fmt.Sprintf("Removed %d logged minutes", worklog.DurationMinutes), changes...)
+	if err != nil {
+		http.Error(w, "Error logging worklog deletion", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error while deleting worklog", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Worklog deleted successfully"))
+}
+
+func (app *App) GetTimesheet(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	requesterID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, err, "Invalid date range")
+		return
+	}
+
+	// Only worklogs on live tasks in projects the requester belongs to are listed.
+	rows, err := app.DB.Query("SELECT "+worklogColumns+` FROM worklogs
+		JOIN tasks ON tasks.id = worklogs.task_id
+		JOIN columns ON columns.id = tasks.column_id
+		JOIN boards ON boards.id = columns.board_id
+		WHERE worklogs.user_id = $1 AND worklogs.work_date BETWEEN $2 AND $3 AND tasks.deleted_at IS NULL
+			AND boards.project_id IN (SELECT project_id FROM project_users WHERE user_id = $4)
+		ORDER BY worklogs.work_date, worklogs.id`, userID, from.Format(dateLayout), to.Format(dateLayout), requesterID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	timesheet := types.Timesheet{
+		UserID:   userID,
+		From:     from.Format(dateLayout),
+		To:       to.Format(dateLayout),
+		Days:     []types.TimesheetDay{},
+		Worklogs: []types.Worklog{},
+	}
+	for rows.Next() {
+		var worklog types.Worklog
+		if err := scanWorklog(rows, &worklog); err != nil {
+			http.Error(w, "Error scanning worklogs", http.StatusInternalServerError)
+			return
+		}
+		timesheet.TotalMinutes += worklog.DurationMinutes
+		if n := len(timesheet.Days); n > 0 && timesheet.Days[n-1].Date == worklog.WorkDate {
+			timesheet.Days[n-1].Minutes += worklog.DurationMinutes
+		} else {
+			timesheet.Days = append(timesheet.Days, types.TimesheetDay{Date: worklog.WorkDate, Minutes: worklog.DurationMinutes})
+		}
+		timesheet.Worklogs = append(timesheet.Worklogs, worklog)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timesheet)
+}
+
+func (app *App) ExportWorklogsCSV(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := app.userIDFromRequest(r)
+	if err != nil {
+		respondError(w, err, "Database error while authenticating user")
+		return
+	}
+
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, err, "Invalid date range")
+		return
+	}
+
+	query := "SELECT " + worklogColumns + `, users.username, users.email, projects.name, tasks.title
+		FROM worklogs
+		JOIN users ON users.id = worklogs.user_id
+		JOIN tasks ON tasks.id = worklogs.task_id
+		JOIN columns ON columns.id = tasks.column_id
+		JOIN boards ON boards.id = columns.board_id
+		JOIN projects ON projects.id = boards.project_id
+		WHERE worklogs.work_date BETWEEN $1 AND $2 AND tasks.deleted_at IS NULL
+			AND projects.id IN (SELECT project_id FROM project_users WHERE user_id = $3)`
+	args := []any{from.Format(dateLayout), to.Format(dateLayout), requesterID}
+
+	if param := r.URL.Query().Get("project_id"); param != "" {
+		projectID, err := strconv.Atoi(param)
+		if err != nil {
+			http.Error(w, "Invalid project ID", http.StatusBadRequest)
+			return
+		}
+		if err := app.checkProjectMember(projectID, requesterID); err != nil {
+			respondError(w, err, "Database error while checking project membership")
+			return
+		}
+		args = append(args, projectID)
+		query += fmt.Sprintf(" AND projects.id = $%d", len(args))
+	}
+	if userID := r.URL.Query().Get("user_id"); userID != "" {
+		args = append(args, userID)
+		query += fmt.Sprintf(" AND worklogs.user_id = $%d", len(args))
+	}
+	query += " ORDER BY worklogs.work_date, worklogs.id"
+
+	rows, err := app.DB.Query(query, args...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"worklogs_%s_%s.csv\"", from.Format(dateLayout), to.Format(dateLayout)))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"worklog_id", "date", "username", "email", "project", "task_id", "task_title", "minutes", "hours", "note"})
+	for rows.Next() {
+		var worklog types.Worklog
+		var username, email, projectName, taskTitle string
+		if err := scanWorklog(rows, &worklog, &username, &email, &projectName, &taskTitle); err != nil {
+			log.Printf("Error scanning worklogs for export: %v", err)
+			break
+		}
+		writer.Write([]string{
+			strconv.Itoa(worklog.ID),
+			worklog.WorkDate,
+			username,
+			email,
+			projectName,
+			strconv.Itoa(worklog.TaskID),
+			taskTitle,
+			strconv.Itoa(worklog.DurationMinutes),
+			strconv.FormatFloat(float64(worklog.DurationMinutes)/60, 'f', 2, 64),
+			worklog.Note,
+		})
+	}
+	writer.Flush()
+}
@@ -6,6 +6,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 )
 
 func main() {
@@ -20,11 +22,33 @@ func main() {
 		log.Fatal("JWT_SECRET not set in environment variables")
 	}
 
+	trashRetentionDays := 30
+	if value := os.Getenv("TRASH_RETENTION_DAYS"); value != "" {
+		trashRetentionDays, err = strconv.Atoi(value)
+		if err != nil || trashRetentionDays < 0 {
+			log.Fatal("TRASH_RETENTION_DAYS must be a non-negative number of days")
+		}
+	}
+
 	app := &api.App{
 		DB:     database,
 		JWTKey: jwtKey,
+
+		EnforceBlockers: os.Getenv("ENFORCE_BLOCKERS") == "true",
+		TrashRetention:  time.Duration(trashRetentionDays) * 24 * time.Hour,
+
+		GitWebhookSecret: os.Getenv("GIT_WEBHOOK_SECRET"),
 	}
 
+	app.StartRecurrenceScheduler(time.Minute)
+	app.StartTrashPurger(time.Hour)
+	app.StartReminderScheduler(time.Minute)
+	go func() {
+		if err := app.FillDescriptionCache(); err != nil {
+			log.Printf("Error filling description cache: %v", err)
+		}
+	}()
+
 	r := api.InitRouter(app)
 	log.Println("Routes initialized successfully!")
 
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS task_relations (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    related_task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('blocks', 'relates_to', 'duplicates')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (task_id <> related_task_id),
+    UNIQUE (task_id, related_task_id, type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_relations_related_task_id ON task_relations(related_task_id);
@@ -0,0 +1,15 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_points INT CHECK (estimate_points >= 0);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_hours NUMERIC(8, 2) CHECK (estimate_hours >= 0);
+
+CREATE TABLE IF NOT EXISTS worklogs (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id),
+    duration_minutes INT NOT NULL CHECK (duration_minutes > 0),
+    work_date DATE NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_worklogs_task_id ON worklogs(task_id);
+CREATE INDEX IF NOT EXISTS idx_worklogs_user_date ON worklogs(user_id, work_date);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS custom_fields (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('text', 'number', 'date', 'single_select', 'multi_select', 'user')),
+    options TEXT[] NOT NULL DEFAULT '{}',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (project_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS task_custom_values (
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    field_id INT NOT NULL REFERENCES custom_fields(id) ON DELETE CASCADE,
+    value JSONB NOT NULL,
+    PRIMARY KEY (task_id, field_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_custom_values_field_id ON task_custom_values(field_id);
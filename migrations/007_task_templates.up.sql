@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS task_templates (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    column_id INT REFERENCES columns(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    rrule TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP,
+    next_run_at TIMESTAMP,
+    occurrences INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_templates_next_run_at ON task_templates(next_run_at) WHERE rrule <> '';
+
+CREATE TABLE IF NOT EXISTS task_template_runs (
+    template_id INT NOT NULL REFERENCES task_templates(id) ON DELETE CASCADE,
+    occurrence_at TIMESTAMP NOT NULL,
+    task_id INT REFERENCES tasks(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (template_id, occurrence_at)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS task_labels (
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label VARCHAR(50) NOT NULL,
+    PRIMARY KEY (task_id, label)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_labels_label ON task_labels(label);
+
+CREATE TABLE IF NOT EXISTS task_checklist_items (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_checklist_items_task_id ON task_checklist_items(task_id);
+
+ALTER TABLE task_templates ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE task_templates ADD COLUMN IF NOT EXISTS checklist TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,24 @@
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS key VARCHAR(10);
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS task_counter INT NOT NULL DEFAULT 0;
+
+UPDATE projects SET key = 'P' || id WHERE key IS NULL;
+ALTER TABLE projects ALTER COLUMN key SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_project_key ON projects(key);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS number INT;
+
+UPDATE tasks SET number = numbered.number
+FROM (
+    SELECT tasks.id, ROW_NUMBER() OVER (PARTITION BY boards.project_id ORDER BY tasks.id) AS number
+    FROM tasks
+    JOIN columns ON columns.id = tasks.column_id
+    JOIN boards ON boards.id = columns.board_id
+) numbered
+WHERE tasks.id = numbered.id AND tasks.number IS NULL;
+
+UPDATE projects SET task_counter = COALESCE((
+    SELECT MAX(tasks.number) FROM tasks
+    JOIN columns ON columns.id = tasks.column_id
+    JOIN boards ON boards.id = columns.board_id
+    WHERE boards.project_id = projects.id
+), 0);
@@ -0,0 +1,4 @@
+ALTER TABLE task_logs ADD COLUMN IF NOT EXISTS actor_id INT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE task_logs ADD COLUMN IF NOT EXISTS changes JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX IF NOT EXISTS idx_task_logs_task_id ON task_logs(task_id, id);
@@ -0,0 +1,5 @@
+ALTER TABLE tasks ADD COLUMN description_html TEXT;
+ALTER TABLE tasks ADD COLUMN description_html_hash VARCHAR(64);
+
+ALTER TABLE projects ADD COLUMN description_html TEXT;
+ALTER TABLE projects ADD COLUMN description_html_hash VARCHAR(64);
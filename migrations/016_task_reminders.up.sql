@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS task_reminders (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    remind_at TIMESTAMP,
+    field_id INT REFERENCES custom_fields(id) ON DELETE CASCADE,
+    before_hours INT,
+    note TEXT NOT NULL DEFAULT '',
+    fired_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((remind_at IS NULL) <> (field_id IS NULL)),
+    CHECK ((field_id IS NULL) = (before_hours IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_reminders_user_id ON task_reminders(user_id, task_id);
+CREATE INDEX IF NOT EXISTS idx_task_reminders_pending ON task_reminders(remind_at) WHERE fired_at IS NULL;
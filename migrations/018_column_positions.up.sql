@@ -0,0 +1,7 @@
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS position INT NOT NULL DEFAULT 0;
+
+UPDATE columns SET position = ordered.position
+FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY board_id ORDER BY id) - 1 AS position FROM columns) ordered
+WHERE columns.id = ordered.id;
+
+CREATE INDEX IF NOT EXISTS idx_columns_board_position ON columns(board_id, position) WHERE deleted_at IS NULL;
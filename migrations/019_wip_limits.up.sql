@@ -0,0 +1,4 @@
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS wip_min INT CHECK (wip_min >= 0);
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS wip_max INT CHECK (wip_max >= 1);
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS wip_mode VARCHAR(10) NOT NULL DEFAULT 'soft' CHECK (wip_mode IN ('soft', 'hard'));
+ALTER TABLE columns ADD CONSTRAINT wip_min_not_above_max CHECK (wip_min IS NULL OR wip_max IS NULL OR wip_min <= wip_max);
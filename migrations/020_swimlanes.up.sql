@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS swimlanes (
+    id SERIAL PRIMARY KEY,
+    board_id INT NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (board_id, name)
+);
+
+ALTER TABLE boards ADD COLUMN IF NOT EXISTS swimlane_field VARCHAR(110) NOT NULL DEFAULT '';
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS swimlane_id INT REFERENCES swimlanes(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS position INT;
+
+UPDATE tasks SET position = ordered.position
+FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY column_id ORDER BY id) - 1 AS position FROM tasks) ordered
+WHERE tasks.id = ordered.id;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_swimlane_id ON tasks(swimlane_id);
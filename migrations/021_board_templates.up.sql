@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS board_templates (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    columns JSONB NOT NULL DEFAULT '[]',
+    labels TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (project_id, name)
+);
+
+ALTER TABLE boards ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
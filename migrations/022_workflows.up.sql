@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS workflow_transitions (
+    id SERIAL PRIMARY KEY,
+    board_id INT NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    from_column_id INT NOT NULL REFERENCES columns(id) ON DELETE CASCADE,
+    to_column_id INT NOT NULL REFERENCES columns(id) ON DELETE CASCADE,
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    required_fields TEXT[] NOT NULL DEFAULT '{}',
+    UNIQUE (from_column_id, to_column_id),
+    CHECK (from_column_id <> to_column_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_transitions_board_id ON workflow_transitions(board_id);
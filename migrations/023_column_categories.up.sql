@@ -0,0 +1,20 @@
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS category VARCHAR(20) NOT NULL DEFAULT 'todo'
+    CHECK (category IN ('backlog', 'todo', 'in-progress', 'done'));
+
+UPDATE columns SET category = CASE
+    WHEN status = 'done' THEN 'done'
+    WHEN status IN ('doing', 'in progress', 'review', 'verifying') THEN 'in-progress'
+    WHEN status IN ('backlog', 'new') THEN 'backlog'
+    ELSE 'todo'
+END;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS started_at TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+UPDATE tasks SET
+    started_at = tasks.created_at,
+    completed_at = CASE WHEN columns.category = 'done' THEN COALESCE(
+        (SELECT MAX(task_logs.created_at) FROM task_logs WHERE task_logs.task_id = tasks.id AND task_logs.action_type = 'move'),
+        tasks.created_at) END
+FROM columns
+WHERE columns.id = tasks.column_id AND columns.category IN ('in-progress', 'done');
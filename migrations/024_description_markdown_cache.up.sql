@@ -0,0 +1,2 @@
+UPDATE tasks SET description_html = NULL, description_html_hash = NULL;
+UPDATE projects SET description_html = NULL, description_html_hash = NULL;
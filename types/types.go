@@ -24,41 +24,409 @@ type UserResponse struct {
 }
 
 type Project struct {
-	ID          int       `json:"id"`
-	UserID      int       `json:"user_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	Boards      []Board   `json:"boards,omitempty"`
+	ID              int       `json:"id"`
+	UserID          int       `json:"user_id"`
+	Key             string    `json:"key"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	DescriptionHTML string    `json:"description_html,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	Boards          []Board   `json:"boards,omitempty"`
 }
 
 type Board struct {
-	ID        int      `json:"id"`
-	ProjectID int      `json:"project_id"`
-	Name      string   `json:"name"`
-	Columns   []Column `json:"columns,omitempty"`
+	ID            int        `json:"id"`
+	ProjectID     int        `json:"project_id"`
+	Name          string     `json:"name"`
+	SwimlaneField string     `json:"swimlane_field,omitempty"`
+	Template      string     `json:"template,omitempty"`
+	Labels        []string   `json:"labels,omitempty"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty"`
+	Columns       []Column   `json:"columns,omitempty"`
+}
+
+type WorkflowTransition struct {
+	ID             int      `json:"id,omitempty"`
+	FromColumnID   int      `json:"from_column_id"`
+	ToColumnID     int      `json:"to_column_id"`
+	Roles          []string `json:"roles"`
+	RequiredFields []string `json:"required_fields"`
+}
+
+type Workflow struct {
+	BoardID     int                  `json:"board_id"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+type BoardTemplateColumn struct {
+	Status   string `json:"status"`
+	Category string `json:"category"`
+	WIPMin   *int   `json:"wip_min,omitempty"`
+	WIPMax   *int   `json:"wip_max,omitempty"`
+	WIPMode  string `json:"wip_mode,omitempty"`
+}
+
+type BoardTemplate struct {
+	ID          int                   `json:"id,omitempty"`
+	Key         string                `json:"key,omitempty"`
+	ProjectID   *int                  `json:"project_id,omitempty"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Columns     []BoardTemplateColumn `json:"columns"`
+	Labels      []string              `json:"labels"`
+	BuiltIn     bool                  `json:"built_in"`
+	CreatedAt   *time.Time            `json:"created_at,omitempty"`
+}
+
+type Swimlane struct {
+	ID        int       `json:"id"`
+	BoardID   int       `json:"board_id"`
+	Name      string    `json:"name"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SwimlaneOrder struct {
+	SwimlaneIDs []int `json:"swimlane_ids"`
+}
+
+type SwimlaneField struct {
+	Field string `json:"field"`
+}
+
+type TaskPlacement struct {
+	SwimlaneID *int `json:"swimlane_id"`
+	Position   *int `json:"position"`
+}
+
+type LayoutCell struct {
+	ColumnID int    `json:"column_id"`
+	Tasks    []Task `json:"tasks"`
+}
+
+type LayoutLane struct {
+	ID    *int         `json:"id,omitempty"`
+	Key   string       `json:"key"`
+	Name  string       `json:"name"`
+	Cells []LayoutCell `json:"cells"`
+}
+
+type BoardLayout struct {
+	BoardID       int          `json:"board_id"`
+	SwimlaneField string       `json:"swimlane_field"`
+	Columns       []Column     `json:"columns"`
+	Lanes         []LayoutLane `json:"lanes"`
 }
 
 type Column struct {
-	ID      int    `json:"id"`
-	BoardID int    `json:"board_id"`
-	Status  string `json:"status"`
-	Tasks   []Task `json:"tasks,omitempty"`
+	ID        int    `json:"id"`
+	BoardID   int    `json:"board_id"`
+	Status    string `json:"status"`
+	Category  string `json:"category"`
+	Position  int    `json:"position"`
+	WIPMin    *int   `json:"wip_min,omitempty"`
+	WIPMax    *int   `json:"wip_max,omitempty"`
+	WIPMode   string `json:"wip_mode,omitempty"`
+	Load      int    `json:"load"`
+	WIPStatus string `json:"wip_status,omitempty"`
+	Tasks     []Task `json:"tasks,omitempty"`
+}
+
+type ColumnCategory struct {
+	Category string `json:"category"`
+}
+
+type WIPLimits struct {
+	WIPMin  *int   `json:"wip_min"`
+	WIPMax  *int   `json:"wip_max"`
+	WIPMode string `json:"wip_mode"`
+}
+
+type ColumnOrder struct {
+	ColumnIDs []int `json:"column_ids"`
 }
 
 type Task struct {
-	ID          int       `json:"id"`
-	ColumnID    int       `json:"column_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	TaskLogs    []TaskLog `json:"task_logs,omitempty"`
+	ID              int             `json:"id"`
+	Key             string          `json:"key,omitempty"`
+	ColumnID        int             `json:"column_id"`
+	ParentID        *int            `json:"parent_id,omitempty"`
+	Title           string          `json:"title"`
+	Description     string          `json:"description"`
+	DescriptionHTML string          `json:"description_html,omitempty"`
+	EstimatePoints  *int            `json:"estimate_points,omitempty"`
+	EstimateHours   *float64        `json:"estimate_hours,omitempty"`
+	CreatedBy       *int            `json:"created_by,omitempty"`
+	AssigneeID      *int            `json:"assignee_id,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	ArchivedAt      *time.Time      `json:"archived_at,omitempty"`
+	StartedAt       *time.Time      `json:"started_at,omitempty"`
+	CompletedAt     *time.Time      `json:"completed_at,omitempty"`
+	SwimlaneID      *int            `json:"swimlane_id,omitempty"`
+	Position        *int            `json:"position,omitempty"`
+	CustomFields    map[string]any  `json:"custom_fields,omitempty"`
+	Labels          []string        `json:"labels,omitempty"`
+	Checklist       []ChecklistItem `json:"checklist,omitempty"`
+	Progress        *Progress       `json:"progress,omitempty"`
+	Children        []Task          `json:"children,omitempty"`
+	TaskLogs        []TaskLog       `json:"task_logs,omitempty"`
+	BlockedBy       []Blocker       `json:"blocked_by,omitempty"`
+	Reactions       []ReactionCount `json:"reactions,omitempty"`
+	Votes           int             `json:"votes"`
+	Links           []TaskLink      `json:"links,omitempty"`
+	Warnings        []string        `json:"warnings,omitempty"`
+}
+
+type TaskLink struct {
+	ID        int            `json:"id"`
+	TaskID    int            `json:"task_id"`
+	Type      string         `json:"type"`
+	URL       string         `json:"url"`
+	Title     string         `json:"title"`
+	Metadata  map[string]any `json:"metadata"`
+	CreatedBy *int           `json:"created_by,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type GitCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	URL     string `json:"url"`
+	Author  string `json:"author"`
+}
+
+type GitPullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+}
+
+type GitEvent struct {
+	Repository  string          `json:"repository"`
+	Branch      string          `json:"branch"`
+	Commits     []GitCommit     `json:"commits"`
+	PullRequest *GitPullRequest `json:"pull_request,omitempty"`
+}
+
+type GitEventResult struct {
+	Linked []TaskLink `json:"linked"`
+}
+
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+type Reaction struct {
+	Emoji string `json:"emoji"`
 }
 
 type TaskLog struct {
-	ID         int       `json:"id"`
-	TaskID     int       `json:"task_id"`
-	ActionType string    `json:"action_type"`
-	LogMessage string    `json:"log_message"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         int           `json:"id"`
+	TaskID     int           `json:"task_id"`
+	ActorID    *int          `json:"actor_id,omitempty"`
+	ActionType string        `json:"action_type"`
+	LogMessage string        `json:"log_message"`
+	Changes    []FieldChange `json:"changes"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+type TaskRevision struct {
+	RevisionID      int            `json:"revision_id"`
+	CurrentRevision int            `json:"current_revision"`
+	Fields          map[string]any `json:"fields"`
+	Changes         []FieldChange  `json:"changes"`
+}
+
+type TaskRevert struct {
+	RevisionID       int `json:"revision_id"`
+	ExpectedRevision int `json:"expected_revision"`
+}
+
+type TaskLogPage struct {
+	Logs   []TaskLog `json:"logs"`
+	Total  int       `json:"total"`
+	Limit  int       `json:"limit"`
+	Offset int       `json:"offset"`
+}
+
+type TaskRelation struct {
+	ID            int       `json:"id"`
+	TaskID        int       `json:"task_id"`
+	RelatedTaskID int       `json:"related_task_id"`
+	Type          string    `json:"type"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Blocker struct {
+	TaskID   int    `json:"task_id"`
+	Title    string `json:"title"`
+	ColumnID int    `json:"column_id"`
+	Resolved bool   `json:"resolved"`
+}
+
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+type Worklog struct {
+	ID              int       `json:"id"`
+	TaskID          int       `json:"task_id"`
+	UserID          int       `json:"user_id"`
+	DurationMinutes int       `json:"duration_minutes"`
+	WorkDate        string    `json:"work_date"`
+	Note            string    `json:"note"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type TimeTracking struct {
+	EstimateHours  *float64  `json:"estimate_hours,omitempty"`
+	LoggedHours    float64   `json:"logged_hours"`
+	RemainingHours *float64  `json:"remaining_hours,omitempty"`
+	Worklogs       []Worklog `json:"worklogs"`
+}
+
+type TimesheetDay struct {
+	Date    string `json:"date"`
+	Minutes int    `json:"minutes"`
+}
+
+type Timesheet struct {
+	UserID       int            `json:"user_id"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	TotalMinutes int            `json:"total_minutes"`
+	Days         []TimesheetDay `json:"days"`
+	Worklogs     []Worklog      `json:"worklogs"`
+}
+
+type CustomField struct {
+	ID        int       `json:"id"`
+	ProjectID int       `json:"project_id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options,omitempty"`
+	Required  bool      `json:"required"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Watcher struct {
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Notification struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	TaskID     int        `json:"task_id"`
+	ActionType string     `json:"action_type"`
+	Message    string     `json:"message"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type Reminder struct {
+	ID          int        `json:"id"`
+	TaskID      int        `json:"task_id"`
+	UserID      int        `json:"user_id"`
+	RemindAt    *time.Time `json:"remind_at,omitempty"`
+	Field       string     `json:"field,omitempty"`
+	BeforeHours *int       `json:"before_hours,omitempty"`
+	Note        string     `json:"note"`
+	FiredAt     *time.Time `json:"fired_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type TaskTemplate struct {
+	ID          int        `json:"id"`
+	ProjectID   int        `json:"project_id"`
+	ColumnID    *int       `json:"column_id,omitempty"`
+	Name        string     `json:"name"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Labels      []string   `json:"labels"`
+	Checklist   []string   `json:"checklist"`
+	RRule       string     `json:"rrule,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+	Occurrences int        `json:"occurrences"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type ChecklistItem struct {
+	ID       int    `json:"id"`
+	TaskID   int    `json:"task_id"`
+	Content  string `json:"content"`
+	Done     bool   `json:"done"`
+	Position int    `json:"position"`
+}
+
+type TemplateInstance struct {
+	ColumnID  *int              `json:"column_id,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+type TrashItem struct {
+	Type      string     `json:"type"`
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	DeletedBy *int       `json:"deleted_by,omitempty"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty"`
+}
+
+type BulkTaskFilter struct {
+	ProjectID       int    `json:"project_id,omitempty"`
+	ColumnID        int    `json:"column_id,omitempty"`
+	AssigneeID      int    `json:"assignee_id,omitempty"`
+	Label           string `json:"label,omitempty"`
+	IncludeArchived bool   `json:"include_archived,omitempty"`
+}
+
+type BulkTaskRequest struct {
+	Operation  string          `json:"operation"`
+	TaskIDs    []int           `json:"task_ids,omitempty"`
+	Filter     *BulkTaskFilter `json:"filter,omitempty"`
+	ColumnID   int             `json:"column_id,omitempty"`
+	AssigneeID *int            `json:"assignee_id,omitempty"`
+	Labels     []string        `json:"labels,omitempty"`
+}
+
+type BulkTaskResult struct {
+	Operation string   `json:"operation"`
+	Updated   int      `json:"updated"`
+	TaskIDs   []int    `json:"task_ids"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+type SimilarTask struct {
+	ID         int     `json:"id"`
+	Key        string  `json:"key"`
+	Title      string  `json:"title"`
+	ColumnID   int     `json:"column_id"`
+	Similarity float64 `json:"similarity"`
+}
+
+type DuplicateWarning struct {
+	Message string        `json:"message"`
+	Similar []SimilarTask `json:"similar"`
+}
+
+type CloneRequest struct {
+	Name             string `json:"name,omitempty"`
+	ProjectID        int    `json:"project_id,omitempty"`
+	ColumnID         int    `json:"column_id,omitempty"`
+	IncludeTasks     bool   `json:"include_tasks,omitempty"`
+	IncludeLabels    bool   `json:"include_labels,omitempty"`
+	IncludeChecklist bool   `json:"include_checklist,omitempty"`
 }